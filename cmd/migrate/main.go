@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/bot/interactions/migrator"
+	"github.com/elliotwms/pinbot/internal/commands"
+)
+
+// main registers Pinbot's application commands with Discord. Commands are registered globally unless DISCORD_GUILD_ID
+// is set, in which case they are registered in that guild only (useful for testing).
+func main() {
+	s, err := discordgo.New("Bot " + os.Getenv("DISCORD_TOKEN"))
+	if err != nil {
+		panic(err)
+	}
+
+	var opts []migrator.Option
+	if id := os.Getenv("DISCORD_GUILD_ID"); id != "" {
+		opts = append(opts, migrator.WithGuildID(id))
+	}
+
+	m := migrator.New(s, os.Getenv("DISCORD_APPLICATION_ID"), opts...)
+	for _, c := range commands.Commands {
+		m.WithApplicationCommand(c)
+	}
+
+	if err := m.Migrate(context.Background()); err != nil {
+		panic(err)
+	}
+
+	slog.Info("Migrated commands", "count", len(commands.Commands))
+}
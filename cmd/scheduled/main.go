@@ -0,0 +1,34 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/elliotwms/bot-lambda/sessionprovider"
+	"github.com/elliotwms/pinbot/internal/pinbot"
+	"github.com/elliotwms/pinbot/internal/store"
+)
+
+func init() {
+	if strings.ToLower(os.Getenv("DEBUG")) == "true" {
+		slog.SetLogLoggerLevel(slog.LevelDebug)
+	}
+}
+
+// Version describes the build version
+// it should be set via ldflags when building
+var Version = "v0.0.0+unknown"
+
+// main runs Pinbot's scheduled jobs, triggered by EventBridge schedule rules
+func main() {
+	logger := slog.Default().With(slog.String("version", Version))
+
+	src := sessionprovider.Cached(sessionprovider.ParamStore(
+		os.Getenv("PARAM_DISCORD_TOKEN"),
+	))
+	sc := pinbot.NewScheduler(src, logger, pinbot.WithStore(store.FromEnv(logger)))
+
+	lambda.StartWithOptions(sc.HandleEvent)
+}
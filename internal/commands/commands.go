@@ -0,0 +1,389 @@
+package commands
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// Names of the application commands handled by Pinbot
+const (
+	Pin         = "Pin"
+	PinTo       = "Pin to…"
+	PinWithNote = "Pin with note"
+	RefreshPin  = "Refresh pin"
+	Pins        = "pins"
+	Pinbot      = "pinbot"
+)
+
+// Subcommands of the pins command
+const (
+	PinsSearch = "search"
+	PinsRandom = "random"
+	PinsStats  = "stats"
+	PinsExport = "export"
+	PinsRotate = "rotate"
+)
+
+// Subcommands of the pinbot command
+const (
+	PinbotBackfill = "backfill"
+	PinbotVerify   = "verify"
+)
+
+// Options of the pins subcommands
+const (
+	OptionQuery   = "query"
+	OptionAuthor  = "author"
+	OptionPinner  = "pinner"
+	OptionChannel = "channel"
+	OptionTag     = "tag"
+	OptionFrom    = "from"
+	OptionTo      = "to"
+
+	OptionExcludeDays = "exclude_days"
+	OptionCSV         = "csv"
+	OptionFormat      = "format"
+)
+
+// Options of the pinbot subcommands
+const (
+	OptionBefore = "before"
+)
+
+// export formats
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatHTML = "html"
+)
+
+// dateLength is the length of a date option in the format YYYY-MM-DD
+var dateLength = 10
+
+var minExcludeDays = 1.0
+
+var guildOnly = &[]discordgo.InteractionContextType{discordgo.InteractionContextGuild}
+
+var manageGuild int64 = discordgo.PermissionManageGuild
+
+// Commands are the application commands handled by Pinbot, which are registered with Discord by cmd/migrate
+var Commands = []*discordgo.ApplicationCommand{
+	{
+		Name:              Pin,
+		NameLocalizations: localizedRef("Anheften", "Épingler", "Fijar", "Fixar"),
+		Type:              discordgo.MessageApplicationCommand,
+		Contexts:          guildOnly,
+	},
+	{
+		Name:              PinTo,
+		NameLocalizations: localizedRef("Anheften in…", "Épingler dans…", "Fijar en…", "Fixar em…"),
+		Type:              discordgo.MessageApplicationCommand,
+		Contexts:          guildOnly,
+	},
+	{
+		Name:              PinWithNote,
+		NameLocalizations: localizedRef("Mit Notiz anheften", "Épingler avec une note", "Fijar con nota", "Fixar com nota"),
+		Type:              discordgo.MessageApplicationCommand,
+		Contexts:          guildOnly,
+	},
+	{
+		Name:              RefreshPin,
+		NameLocalizations: localizedRef("Pin aktualisieren", "Actualiser l'épingle", "Actualizar fijado", "Atualizar fixado"),
+		Type:              discordgo.MessageApplicationCommand,
+		Contexts:          guildOnly,
+	},
+	{
+		Name:        Pins,
+		Type:        discordgo.ChatApplicationCommand,
+		Description: "Browse the server's pins",
+		DescriptionLocalizations: localizedRef(
+			"Durchsuche die Pins des Servers",
+			"Parcourir les épingles du serveur",
+			"Explora los mensajes fijados del servidor",
+			"Navegue pelas mensagens fixadas do servidor",
+		),
+		Contexts: guildOnly,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        PinsSearch,
+				Description: "Search the server's pins",
+				DescriptionLocalizations: localized(
+					"Durchsuche die Pins des Servers",
+					"Rechercher dans les épingles du serveur",
+					"Busca en los mensajes fijados del servidor",
+					"Pesquise nas mensagens fixadas do servidor",
+				),
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        OptionQuery,
+						Description: "Text to search for",
+						DescriptionLocalizations: localized(
+							"Zu suchender Text",
+							"Texte à rechercher",
+							"Texto que buscar",
+							"Texto a pesquisar",
+						),
+						MaxLength: 25,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        OptionAuthor,
+						Description: "Author of the pinned message",
+						DescriptionLocalizations: localized(
+							"Autor der angehefteten Nachricht",
+							"Auteur du message épinglé",
+							"Autor del mensaje fijado",
+							"Autor da mensagem fixada",
+						),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        OptionPinner,
+						Description: "User who pinned the message",
+						DescriptionLocalizations: localized(
+							"Nutzer, der die Nachricht angeheftet hat",
+							"Utilisateur qui a épinglé le message",
+							"Usuario que fijó el mensaje",
+							"Usuário que fixou a mensagem",
+						),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        OptionChannel,
+						Description: "Channel the message was posted in",
+						DescriptionLocalizations: localized(
+							"Kanal, in dem die Nachricht gepostet wurde",
+							"Salon où le message a été publié",
+							"Canal en el que se publicó el mensaje",
+							"Canal em que a mensagem foi publicada",
+						),
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        OptionTag,
+						Description: "Tag attached to the pin",
+						DescriptionLocalizations: localized(
+							"Tag des Pins",
+							"Étiquette de l'épingle",
+							"Etiqueta del mensaje fijado",
+							"Etiqueta da mensagem fixada",
+						),
+						MaxLength: 25,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        OptionFrom,
+						Description: "Earliest date the message was posted (YYYY-MM-DD)",
+						DescriptionLocalizations: localized(
+							"Frühestes Datum, an dem die Nachricht gepostet wurde (JJJJ-MM-TT)",
+							"Date de publication la plus ancienne (AAAA-MM-JJ)",
+							"Fecha de publicación más temprana (AAAA-MM-DD)",
+							"Data de publicação mais antiga (AAAA-MM-DD)",
+						),
+						MinLength: &dateLength,
+						MaxLength: dateLength,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        OptionTo,
+						Description: "Latest date the message was posted (YYYY-MM-DD)",
+						DescriptionLocalizations: localized(
+							"Spätestes Datum, an dem die Nachricht gepostet wurde (JJJJ-MM-TT)",
+							"Date de publication la plus récente (AAAA-MM-JJ)",
+							"Fecha de publicación más tardía (AAAA-MM-DD)",
+							"Data de publicação mais recente (AAAA-MM-DD)",
+						),
+						MinLength: &dateLength,
+						MaxLength: dateLength,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        PinsRandom,
+				Description: "Post a random pin in this channel",
+				DescriptionLocalizations: localized(
+					"Poste einen zufälligen Pin in diesem Kanal",
+					"Publier une épingle au hasard dans ce salon",
+					"Publica un mensaje fijado al azar en este canal",
+					"Publique uma mensagem fixada aleatória neste canal",
+				),
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        OptionChannel,
+						Description: "Channel the message was posted in",
+						DescriptionLocalizations: localized(
+							"Kanal, in dem die Nachricht gepostet wurde",
+							"Salon où le message a été publié",
+							"Canal en el que se publicó el mensaje",
+							"Canal em que a mensagem foi publicada",
+						),
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        OptionAuthor,
+						Description: "Author of the pinned message",
+						DescriptionLocalizations: localized(
+							"Autor der angehefteten Nachricht",
+							"Auteur du message épinglé",
+							"Autor del mensaje fijado",
+							"Autor da mensagem fixada",
+						),
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        OptionExcludeDays,
+						Description: "Exclude pins from the last number of days",
+						DescriptionLocalizations: localized(
+							"Pins der letzten Anzahl von Tagen ausschließen",
+							"Exclure les épingles des derniers jours",
+							"Excluye los mensajes fijados de los últimos días",
+							"Exclua as mensagens fixadas dos últimos dias",
+						),
+						MinValue: &minExcludeDays,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        PinsStats,
+				Description: "Show the server's pin statistics",
+				DescriptionLocalizations: localized(
+					"Zeige die Pin-Statistiken des Servers",
+					"Afficher les statistiques d'épingles du serveur",
+					"Muestra las estadísticas de mensajes fijados del servidor",
+					"Mostre as estatísticas de mensagens fixadas do servidor",
+				),
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        OptionCSV,
+						Description: "Attach the full breakdown as a CSV file",
+						DescriptionLocalizations: localized(
+							"Die vollständige Aufschlüsselung als CSV-Datei anhängen",
+							"Joindre le détail complet en fichier CSV",
+							"Adjunta el desglose completo como archivo CSV",
+							"Anexe o detalhamento completo como arquivo CSV",
+						),
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        PinsExport,
+				Description: "Export all the server's pins as a file",
+				DescriptionLocalizations: localized(
+					"Exportiere alle Pins des Servers als Datei",
+					"Exporter toutes les épingles du serveur dans un fichier",
+					"Exporta todos los mensajes fijados del servidor como archivo",
+					"Exporte todas as mensagens fixadas do servidor como arquivo",
+				),
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        OptionFormat,
+						Description: "Format of the export",
+						DescriptionLocalizations: localized(
+							"Format des Exports",
+							"Format de l'export",
+							"Formato de la exportación",
+							"Formato da exportação",
+						),
+						Required: true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "JSON", Value: FormatJSON},
+							{Name: "CSV", Value: FormatCSV},
+							{Name: "HTML gallery", NameLocalizations: localized("HTML-Galerie", "Galerie HTML", "Galería HTML", "Galeria HTML"), Value: FormatHTML},
+						},
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        PinsRotate,
+				Description: "Archive and unpin the oldest pins of channels nearing the pin limit",
+				DescriptionLocalizations: localized(
+					"Archiviere und löse die ältesten Pins von Kanälen nahe dem Pin-Limit",
+					"Archiver et désépingler les plus anciennes épingles des salons proches de la limite",
+					"Archiva y desfija los mensajes fijados más antiguos de los canales cerca del límite",
+					"Arquive e desafixe as mensagens fixadas mais antigas dos canais perto do limite",
+				),
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        OptionChannel,
+						Description: "Channel to rotate, instead of every channel",
+						DescriptionLocalizations: localized(
+							"Zu rotierender Kanal, statt aller Kanäle",
+							"Salon à traiter, au lieu de tous les salons",
+							"Canal que rotar, en lugar de todos los canales",
+							"Canal a rotacionar, em vez de todos os canais",
+						),
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+				},
+			},
+		},
+	}, {
+		Name:                     Pinbot,
+		Type:                     discordgo.ChatApplicationCommand,
+		Description:              "Manage Pinbot",
+		DescriptionLocalizations: localizedRef("Pinbot verwalten", "Gérer Pinbot", "Gestionar Pinbot", "Gerenciar o Pinbot"),
+		Contexts:                 guildOnly,
+		DefaultMemberPermissions: &manageGuild,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        PinbotBackfill,
+				Description: "Index the pins previously posted in a pins channel",
+				DescriptionLocalizations: localized(
+					"Indexiere die zuvor in einem Pin-Kanal geposteten Pins",
+					"Indexer les épingles déjà publiées dans un salon d'épingles",
+					"Indexa los mensajes fijados publicados antes en un canal de fijados",
+					"Indexe as mensagens fixadas publicadas antes em um canal de fixados",
+				),
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionChannel,
+						Name:        OptionChannel,
+						Description: "Pins channel to index",
+						DescriptionLocalizations: localized(
+							"Zu indexierender Pin-Kanal",
+							"Salon d'épingles à indexer",
+							"Canal de fijados que indexar",
+							"Canal de fixados a indexar",
+						),
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        OptionBefore,
+						Description: "ID of the message to resume a previous backfill before",
+						DescriptionLocalizations: localized(
+							"ID der Nachricht, vor der eine vorherige Indexierung fortgesetzt wird",
+							"ID du message avant lequel reprendre une indexation précédente",
+							"ID del mensaje antes del que reanudar una indexación anterior",
+							"ID da mensagem antes da qual retomar uma indexação anterior",
+						),
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        PinbotVerify,
+				Description: "Check which pins' original messages have been deleted",
+				DescriptionLocalizations: localized(
+					"Prüfe, welche Originalnachrichten von Pins gelöscht wurden",
+					"Vérifier quels messages d'origine des épingles ont été supprimés",
+					"Comprueba qué mensajes originales de los fijados se han eliminado",
+					"Verifique quais mensagens originais das fixadas foram excluídas",
+				),
+			},
+		},
+	},
+}
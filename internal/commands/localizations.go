@@ -0,0 +1,21 @@
+package commands
+
+import "github.com/bwmarrin/discordgo"
+
+// localized returns the translations of a command name or description in the locales Pinbot supports, in addition to
+// the English default: German, French, Spanish and Brazilian Portuguese
+func localized(de, fr, es, pt string) map[discordgo.Locale]string {
+	return map[discordgo.Locale]string{
+		discordgo.German:       de,
+		discordgo.French:       fr,
+		discordgo.SpanishES:    es,
+		discordgo.SpanishLATAM: es,
+		discordgo.PortugueseBR: pt,
+	}
+}
+
+// localizedRef returns the translations as a reference, as used by the top level of commands
+func localizedRef(de, fr, es, pt string) *map[discordgo.Locale]string {
+	l := localized(de, fr, es, pt)
+	return &l
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/commands"
+)
+
+// PinbotCommandHandler handles the /pinbot command, which manages Pinbot in the guild, dispatching to the handler for
+// its subcommand
+func (h *Handler) PinbotCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) (err error) {
+	if len(data.Options) != 1 {
+		return fmt.Errorf("unexpected pinbot command options: %d", len(data.Options))
+	}
+
+	// the command's default permissions can be overridden by the guild, so check them here too
+	if !canManageGuild(i) {
+		return respondLocalized(ctx, s, i.Interaction, textManagePinbotDenied)
+	}
+
+	o := data.Options[0]
+
+	switch o.Name {
+	case commands.PinbotBackfill:
+		return h.backfill(ctx, s, i, o)
+	case commands.PinbotVerify:
+		return h.verifyCommand(ctx, s, i)
+	default:
+		return fmt.Errorf("unknown pinbot subcommand: %s", o.Name)
+	}
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/commands"
+	"github.com/elliotwms/pinbot/internal/store"
+)
+
+const (
+	// maxMessagesPage is the maximum number of messages Discord returns in a page of channel history
+	maxMessagesPage = 100
+
+	// maxBackfillMessages is the most messages read by each backfill, so that it responds well within the
+	// interaction's time limit. Longer histories are backfilled over several runs, each resuming where the last
+	// stopped.
+	maxBackfillMessages = 1000
+)
+
+var errNotPinMessage = errors.New("not a pin message")
+
+var (
+	jumpLinkPattern = regexp.MustCompile(`^https://discord\.com/channels/(\d+)/(\d+)/(\d+)$`)
+	mentionPattern  = regexp.MustCompile(`^<@!?(\d+)>$`)
+
+	// avatarURLPattern matches the URL of a user's avatar, or of their avatar in a guild, capturing the user's ID and
+	// the hash of their user avatar
+	avatarURLPattern = regexp.MustCompile(`^https://cdn\.discordapp\.com/(?:avatars/(\d+)/(\w+)|guilds/\d+/users/(\d+)/avatars/\w+)\.`)
+)
+
+// backfill pages through the history of a pins channel, recording the pins in the Pinbot messages it finds. Pins which
+// are already recorded gain the message as a target, so the backfill can safely be repeated. Each run reads at most
+// maxBackfillMessages, newest first, before responding with the message to resume before.
+func (h *Handler) backfill(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, o *discordgo.ApplicationCommandInteractionDataOption) error {
+	channelID := optionValue(o, commands.OptionChannel)
+	before := optionValue(o, commands.OptionBefore)
+
+	log := slog.With("guild_id", i.GuildID, "channel_id", channelID, "before", before)
+
+	config, err := h.store.GetGuildConfig(ctx, i.GuildID)
+	if err != nil {
+		log.Error("Could not get guild config", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+	title := withDefaults(config.Template).Title
+
+	var indexed, created, skipped, read int
+	var resume string
+
+	for {
+		messages, err := s.ChannelMessages(channelID, maxMessagesPage, before, "", "", discordgo.WithContext(ctx))
+		if err != nil {
+			log.Error("Could not get channel messages", "error", err)
+			return respondLocalized(ctx, s, i.Interaction, textCouldNotRead, "<#"+channelID+">")
+		}
+
+		for _, m := range messages {
+			if m.Author == nil || m.Author.ID != i.AppID {
+				continue
+			}
+
+			p, err := parsePinMessage(m, title)
+			if errors.Is(err, errNotPinMessage) {
+				continue
+			}
+			if err != nil || p.GuildID != i.GuildID {
+				// mirrored pins are recorded by the guild they were pinned in
+				skipped++
+				continue
+			}
+
+			isNew, err := h.recordBackfill(ctx, p)
+			if err != nil {
+				log.Error("Could not record pin", "message_id", p.MessageID, "error", err)
+				return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+			}
+
+			indexed++
+			if isNew {
+				created++
+			}
+		}
+
+		read += len(messages)
+		if len(messages) < maxMessagesPage {
+			break
+		}
+		before = messages[len(messages)-1].ID
+
+		if read >= maxBackfillMessages {
+			resume = before
+			break
+		}
+	}
+
+	log.Info("Backfilled pins", "indexed", indexed, "created", created, "skipped", skipped, "read", read, "resume", resume)
+
+	l := locale(i.Interaction)
+	content := localize(l, textIndexed, localizeCount(l, textPins, indexed), "<#"+channelID+">", created)
+	if skipped > 0 {
+		content += "\n" + localize(l, textSkipped, localizeCount(l, textPinbotMessages, skipped))
+	}
+	if resume != "" {
+		content += "\n" + localize(l, textBackfillResume, read, resume)
+	}
+
+	return respond(ctx, s, i.Interaction, content)
+}
+
+// recordBackfill records the parsed pin, merging its target into any existing record. Returns true if the record is new.
+func (h *Handler) recordBackfill(ctx context.Context, p *store.Pin) (bool, error) {
+	existing, err := h.store.GetPin(ctx, p.GuildID, p.MessageID)
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return true, h.store.PutPin(ctx, p)
+	case err != nil:
+		return false, err
+	case existing.HasTarget(p.Targets[0].ChannelID):
+		return false, nil
+	}
+
+	existing.Targets = append(existing.Targets, p.Targets...)
+
+	return false, h.store.PutPin(ctx, existing)
+}
+
+// parsePinMessage parses a pin message posted by buildPinMessage in the embed layout back into a pin record. Pin
+// messages only hold the author's ID in the URL of their avatar, so the snapshot of an author with the default avatar
+// only has their username. Messages which aren't pin messages return errNotPinMessage.
+func parsePinMessage(m *discordgo.Message, title string) (*store.Pin, error) {
+	if !isPinMessage(m, title) {
+		return nil, errNotPinMessage
+	}
+	embed := m.Embeds[0]
+
+	match := jumpLinkPattern.FindStringSubmatch(embed.URL)
+	if match == nil {
+		return nil, fmt.Errorf("invalid jump link: %s", embed.URL)
+	}
+	guildID, channelID, messageID := match[1], match[2], match[3]
+
+	source := &discordgo.Message{
+		ID:        messageID,
+		ChannelID: channelID,
+		GuildID:   guildID,
+		Content:   embed.Description,
+	}
+
+	if embed.Author != nil {
+		source.Author = parseAuthor(embed.Author)
+	}
+
+	if t, err := time.Parse(time.RFC3339, embed.Timestamp); err == nil {
+		source.Timestamp = t
+	}
+
+	p := &store.Pin{
+		GuildID:   guildID,
+		ChannelID: channelID,
+		MessageID: messageID,
+		Message:   source,
+		PinnedAt:  m.Timestamp,
+		Targets: []store.Target{{
+			GuildID:   guildID,
+			ChannelID: m.ChannelID,
+			MessageID: m.ID,
+		}},
+	}
+
+	for _, f := range embed.Fields {
+		switch f.Name {
+		case fieldPinnedBy:
+			// pin messages show the pinner's name if it was recorded, which can't be traced back to their ID
+			if match := mentionPattern.FindStringSubmatch(f.Value); match != nil {
+				p.PinnedByID = match[1]
+			} else {
+				p.PinnedByName = f.Value
+			}
+		case fieldNote:
+			p.Note = f.Value
+		case fieldTags:
+			p.Tags = strings.Split(strings.TrimPrefix(f.Value, "🏷️ "), ", ")
+		}
+	}
+
+	// the source message's images follow in their own embeds, before any of the source message's own embeds
+	for n, e := range m.Embeds {
+		if n > 0 && (e.Title != "" || e.Color != embed.Color) {
+			break
+		}
+
+		if e.Image != nil {
+			source.Attachments = append(source.Attachments, parseAttachment(e.Image))
+		}
+	}
+
+	return p, nil
+}
+
+// isPinMessage returns true if the message is a pin message with the title, which is either the guild's or the default
+// title. Reposts of pins, e.g. by /pins random, have the same title and jump link, but are marked as reposts in their
+// footer, or have content in the case of on this day posts.
+func isPinMessage(m *discordgo.Message, title string) bool {
+	if len(m.Embeds) == 0 || m.Content != "" {
+		return false
+	}
+	embed := m.Embeds[0]
+
+	if embed.Title != title && embed.Title != defaultTemplate.Title {
+		return false
+	}
+
+	return embed.Footer == nil || !strings.Contains(embed.Footer.Text, footerReposted)
+}
+
+// parseAuthor parses the author of a pin message's embed, taking their ID and avatar from their avatar's URL if it has
+// them
+func parseAuthor(a *discordgo.MessageEmbedAuthor) *discordgo.User {
+	u := &discordgo.User{Username: a.Name}
+
+	match := avatarURLPattern.FindStringSubmatch(a.IconURL)
+	switch {
+	case match == nil:
+	case match[1] != "":
+		u.ID, u.Avatar = match[1], match[2]
+	default:
+		// guild avatars aren't the user's own avatar
+		u.ID = match[3]
+	}
+
+	return u
+}
+
+// parseAttachment parses an image of a pin message back into an attachment, taking its filename and content type from
+// its URL
+func parseAttachment(image *discordgo.MessageEmbedImage) *discordgo.MessageAttachment {
+	a := &discordgo.MessageAttachment{
+		URL:    image.URL,
+		Width:  image.Width,
+		Height: image.Height,
+	}
+
+	// CDN URLs are signed with query parameters
+	p, _, _ := strings.Cut(image.URL, "?")
+	a.Filename = path.Base(p)
+	a.ContentType = mime.TypeByExtension(path.Ext(p))
+
+	return a
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePinMessage(t *testing.T) {
+	c := testPinContent()
+	c.message.Author.Avatar = "abc123"
+	c.message.Attachments[0].URL += "?ex=1&is=2&hm=3"
+
+	m := &discordgo.Message{
+		ID:        "600",
+		ChannelID: "700",
+		Timestamp: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		Embeds:    buildPinMessage(nil, c).Embeds,
+	}
+
+	p, err := parsePinMessage(m, defaultTemplate.Title)
+	require.NoError(t, err)
+
+	require.Equal(t, "400", p.Message.Author.ID)
+	require.Equal(t, "abc123", p.Message.Author.Avatar)
+	require.Equal(t, "500", p.PinnedByID)
+	require.Equal(t, "this was after the outage", p.Note)
+
+	require.Len(t, p.Message.Attachments, 2)
+	require.Equal(t, "a.png", p.Message.Attachments[0].Filename)
+	require.Equal(t, "image/png", p.Message.Attachments[0].ContentType)
+	require.Equal(t, "b.png", p.Message.Attachments[1].Filename)
+}
+
+func TestParsePinMessageDefaultAvatar(t *testing.T) {
+	m := &discordgo.Message{
+		ID:        "600",
+		ChannelID: "700",
+		Embeds:    buildPinMessage(nil, testPinContent()).Embeds,
+	}
+
+	p, err := parsePinMessage(m, defaultTemplate.Title)
+	require.NoError(t, err)
+
+	// the default avatar doesn't hold the author's ID
+	require.Empty(t, p.Message.Author.ID)
+	require.Equal(t, "author", p.Message.Author.Username)
+
+	st := newStats([]*store.Pin{p})
+	require.Empty(t, st.authors, "authors without an ID shouldn't be counted")
+}
+
+func TestParsePinMessageRejectsReposts(t *testing.T) {
+	p := &store.Pin{GuildID: "100", ChannelID: "200", MessageID: "300", Message: testPinContent().message}
+
+	repost := buildRecordMessage(nil, p)
+	m := &discordgo.Message{ID: "600", ChannelID: "700", Embeds: repost.Embeds}
+
+	_, err := parsePinMessage(m, defaultTemplate.Title)
+	require.ErrorIs(t, err, errNotPinMessage, "reposts carry the pin's jump link, but aren't pin messages")
+
+	// on this day posts are headed by their content
+	m = &discordgo.Message{ID: "600", ChannelID: "700", Content: "📅 On this day in 2024", Embeds: buildPinMessage(nil, testPinContent()).Embeds}
+
+	_, err = parsePinMessage(m, defaultTemplate.Title)
+	require.ErrorIs(t, err, errNotPinMessage)
+}
+
+func TestParsePinMessageCustomTitle(t *testing.T) {
+	template := &store.Template{Title: "⭐ Hall of fame"}
+	m := &discordgo.Message{ID: "600", ChannelID: "700", Embeds: buildPinMessage(template, testPinContent()).Embeds}
+
+	p, err := parsePinMessage(m, template.Title)
+	require.NoError(t, err)
+	require.Equal(t, "300", p.MessageID)
+
+	_, err = parsePinMessage(m, defaultTemplate.Title)
+	require.ErrorIs(t, err, errNotPinMessage, "pin messages are only recognised by the guild's title")
+}
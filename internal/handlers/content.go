@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// memberMentionPattern matches user and role mentions in message content, e.g. <@id>, <@!id> or <@&id>
+var memberMentionPattern = regexp.MustCompile(`<@([!&]?)(\d+)>`)
+
+// everyonePattern matches mentions of everyone in the channel
+var everyonePattern = regexp.MustCompile(`@(everyone|here)`)
+
+// customEmojiPattern matches custom emoji in message content, e.g. <:name:id> or <a:name:id> if animated
+var customEmojiPattern = regexp.MustCompile(`<(a?):(\w+):(\d+)>`)
+
+// noMentions returns allowed mentions which prevent the message from pinging anyone. The bot's messages quote other
+// messages, whose mentions have already pinged once.
+func noMentions() *discordgo.MessageAllowedMentions {
+	return &discordgo.MessageAllowedMentions{Parse: []discordgo.AllowedMentionType{}}
+}
+
+// messageBody returns the text of the message shown on its pin message: its content with its mentions sanitized,
+// followed by its poll and any stickers which can't be shown as images. Custom emoji are preserved, as they render
+// wherever the bot can see them. The resolved data of the interaction which pinned the message may be nil.
+func messageBody(m *discordgo.Message, resolved *discordgo.ApplicationCommandInteractionDataResolved) string {
+	var parts []string
+
+	if m.Content != "" {
+		parts = append(parts, sanitizeMentions(m, resolved))
+	}
+
+	if m.Poll != nil {
+		parts = append(parts, pollText(m.Poll))
+	}
+
+	for _, s := range m.StickerItems {
+		if stickerURL(s) == "" {
+			parts = append(parts, fmt.Sprintf("*Sticker: %s*", s.Name))
+		}
+	}
+
+	return strings.Join(parts, "\n\n")
+}
+
+// sanitizeMentions returns the message content with its user and role mentions replaced by the names of the users and
+// roles, so that they are readable wherever the content is shown and can't ping them again. Names are taken from the
+// resolved data if present, falling back to the users mentioned by the message. Mentions which can't be resolved are
+// left as they are. Mentions of everyone are broken up with a zero-width space.
+func sanitizeMentions(m *discordgo.Message, resolved *discordgo.ApplicationCommandInteractionDataResolved) string {
+	if resolved == nil {
+		resolved = &discordgo.ApplicationCommandInteractionDataResolved{}
+	}
+
+	content := memberMentionPattern.ReplaceAllStringFunc(m.Content, func(mention string) string {
+		match := memberMentionPattern.FindStringSubmatch(mention)
+		id := match[2]
+
+		if match[1] == "&" {
+			if r, ok := resolved.Roles[id]; ok {
+				return "@" + r.Name
+			}
+
+			return mention
+		}
+
+		if member, ok := resolved.Members[id]; ok && member.Nick != "" {
+			return "@" + member.Nick
+		}
+		if u, ok := resolved.Users[id]; ok {
+			return "@" + u.DisplayName()
+		}
+		for _, u := range m.Mentions {
+			if u.ID == id {
+				return "@" + u.DisplayName()
+			}
+		}
+
+		return mention
+	})
+
+	return everyonePattern.ReplaceAllString(content, "@\u200b$1")
+}
+
+// pollText renders the poll as its question followed by its answers, with the number of votes for each if the poll has
+// been finalised
+func pollText(p *discordgo.Poll) string {
+	lines := []string{"📊 **" + p.Question.Text + "**"}
+
+	var counts map[int]int
+	if p.Results != nil && p.Results.Finalized {
+		counts = make(map[int]int, len(p.Results.AnswerCounts))
+		for _, c := range p.Results.AnswerCounts {
+			counts[c.ID] = c.Count
+		}
+	}
+
+	for _, a := range p.Answers {
+		if a.Media == nil {
+			continue
+		}
+
+		answer := a.Media.Text
+		if e := a.Media.Emoji; e != nil {
+			answer = strings.TrimSpace(pollEmoji(e) + " " + answer)
+		}
+
+		if counts != nil {
+			answer += " — " + plural(counts[a.AnswerID], "vote")
+		}
+
+		lines = append(lines, "- "+answer)
+	}
+
+	if counts != nil {
+		lines = append(lines, "-# Final results")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func pollEmoji(e *discordgo.ComponentEmoji) string {
+	if e.ID == "" {
+		return e.Name
+	}
+
+	return (&discordgo.Emoji{ID: e.ID, Name: e.Name, Animated: e.Animated}).MessageFormat()
+}
+
+// stickerURL returns the URL of the sticker's image, or an empty string if it can't be shown as an image
+func stickerURL(s *discordgo.StickerItem) string {
+	switch s.FormatType {
+	case discordgo.StickerFormatTypePNG, discordgo.StickerFormatTypeAPNG:
+		return "https://media.discordapp.net/stickers/" + s.ID + ".png"
+	case discordgo.StickerFormatTypeGIF:
+		return "https://media.discordapp.net/stickers/" + s.ID + ".gif"
+	default:
+		// lottie stickers are animations rather than images
+		return ""
+	}
+}
+
+// messageImages returns the URLs of the images in the message: its image attachments, followed by its stickers.
+// Rehosted attachments are referenced by their copies uploaded with the pin message.
+func messageImages(m *discordgo.Message, rehosted bool) []string {
+	var images []string
+
+	for _, a := range m.Attachments {
+		switch {
+		case !isImage(a):
+			// only show images
+		case rehosted:
+			images = append(images, "attachment://"+rehostName(a))
+		default:
+			images = append(images, a.URL)
+		}
+	}
+
+	for _, s := range m.StickerItems {
+		if u := stickerURL(s); u != "" {
+			images = append(images, u)
+		}
+	}
+
+	return images
+}
+
+// emojiImageURL returns the URL of the custom emoji's image
+func emojiImageURL(id string, animated bool) string {
+	if animated {
+		return "https://cdn.discordapp.com/emojis/" + id + ".gif"
+	}
+
+	return discordgo.EndpointEmoji(id)
+}
+
+// replaceCustomEmoji replaces each custom emoji in the text with the result of the function
+func replaceCustomEmoji(text string, f func(name, id string, animated bool) string) string {
+	return customEmojiPattern.ReplaceAllStringFunc(text, func(e string) string {
+		match := customEmojiPattern.FindStringSubmatch(e)
+
+		return f(match[2], match[3], match[1] == "a")
+	})
+}
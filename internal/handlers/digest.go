@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+	"golang.org/x/sync/errgroup"
+)
+
+// JobDigest routes the weekly scheduled event which posts the digest of recent pins
+const JobDigest = "digest"
+
+const (
+	digestPeriod = 7 * 24 * time.Hour
+	digestTopN   = 10
+
+	// digestConcurrency limits the number of pin messages fetched at once when counting reactions
+	digestConcurrency = 5
+
+	// embed limits, see https://discord.com/developers/docs/resources/message#embed-object-embed-limits
+	maxEmbedFields     = 25
+	maxEmbedFieldValue = 1024
+	maxEmbedTotal      = 6000
+)
+
+// digestEntry is a pin included in the digest, with the number of reactions of any emoji on its pin messages
+type digestEntry struct {
+	pin       *store.Pin
+	reactions int
+}
+
+// DigestJob posts a recap of the past week's pins into the channel configured by each opted-in guild. The most reacted
+// pins are included, grouped by the channel they were pinned from.
+func (h *Handler) DigestJob(ctx context.Context, s *discordgo.Session, t time.Time) error {
+	configs, err := h.store.ListGuildConfigs(ctx)
+	if err != nil {
+		return fmt.Errorf("list guild configs: %w", err)
+	}
+
+	group := errgroup.Group{}
+	for _, c := range configs {
+		if c.Digest == nil {
+			continue
+		}
+
+		group.Go(func() error {
+			if err := h.digest(ctx, s, c, t); err != nil {
+				slog.Error("Could not post digest", "guild_id", c.GuildID, "error", err)
+			}
+
+			return nil
+		})
+	}
+
+	return group.Wait()
+}
+
+func (h *Handler) digest(ctx context.Context, s *discordgo.Session, c *store.GuildConfig, t time.Time) error {
+	pins, err := h.store.ListPins(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	since := t.Add(-digestPeriod)
+	pins = slices.DeleteFunc(pins, func(p *store.Pin) bool {
+		return p.PinnedAt.Before(since) || p.PinnedAt.After(t)
+	})
+
+	log := slog.With("guild_id", c.GuildID, "channel_id", c.Digest.ChannelID)
+
+	// the digest is posted publicly, so pins from private channels are left out
+	everyone, err := newChannelViewer(ctx, s, c.GuildID, everyoneMember())
+	if err != nil {
+		return err
+	}
+
+	pins = slices.DeleteFunc(pins, func(p *store.Pin) bool {
+		return !everyone.canView(ctx, p.ChannelID)
+	})
+
+	if len(pins) == 0 {
+		log.Info("No pins to digest")
+		return nil
+	}
+
+	entries := countReactions(ctx, s, pins)
+
+	slices.SortFunc(entries, func(a, b digestEntry) int {
+		return cmp.Or(b.reactions-a.reactions, a.pin.PinnedAt.Compare(b.pin.PinnedAt))
+	})
+
+	topN := c.Digest.TopN
+	if topN <= 0 {
+		topN = digestTopN
+	}
+	entries = entries[:min(len(entries), topN)]
+
+	_, err = s.ChannelMessageSendComplex(c.Digest.ChannelID, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{buildDigestEmbed(guildLocale(everyone.permissions.guild), everyone.channels, entries, len(pins), since, t)},
+		AllowedMentions: noMentions(),
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	log.Info("Posted digest", "pins", len(pins), "entries", len(entries))
+
+	return nil
+}
+
+// countReactions counts the reactions on each of the pins' pin messages. Pin messages which can't be fetched (e.g.
+// because they have been deleted) count as having no reactions.
+func countReactions(ctx context.Context, s *discordgo.Session, pins []*store.Pin) []digestEntry {
+	entries := make([]digestEntry, len(pins))
+
+	var mu sync.Mutex
+	group := errgroup.Group{}
+	group.SetLimit(digestConcurrency)
+
+	for i, p := range pins {
+		entries[i].pin = p
+
+		for _, t := range p.Targets {
+			group.Go(func() error {
+				m, err := s.ChannelMessage(t.ChannelID, t.MessageID, discordgo.WithContext(ctx))
+				if err != nil {
+					slog.Warn("Could not get pin message", "pin_channel_id", t.ChannelID, "pin_message_id", t.MessageID, "error", err)
+					return nil
+				}
+
+				n := 0
+				for _, r := range m.Reactions {
+					n += r.Count
+				}
+
+				mu.Lock()
+				entries[i].reactions += n
+				mu.Unlock()
+
+				return nil
+			})
+		}
+	}
+
+	_ = group.Wait()
+
+	return entries
+}
+
+// buildDigestEmbed renders the entries in the locale as a field per source channel, in order of each channel's most
+// reacted pin. Pins which would exceed the embed limits are left out.
+func buildDigestEmbed(l discordgo.Locale, channels map[string]*discordgo.Channel, entries []digestEntry, total int, since, t time.Time) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       localize(l, textDigestTitle),
+		Description: localize(l, textDigestSince, localizeCount(l, textPins, total), since.Unix()),
+		Color:       pinMessageColor,
+		Timestamp:   t.Format(time.RFC3339),
+	}
+
+	size := len(embed.Title) + len(embed.Description)
+	fields := map[string]*discordgo.MessageEmbedField{}
+
+	for _, e := range entries {
+		line := fmt.Sprintf("[%s](%s) • %s", excerpt(e.pin.Message), url(e.pin.GuildID, e.pin.ChannelID, e.pin.MessageID), localizeCount(l, textReactions, e.reactions))
+
+		f, ok := fields[e.pin.ChannelID]
+		if !ok {
+			if len(embed.Fields) == maxEmbedFields {
+				continue
+			}
+
+			// field names don't render channel mentions, so use the channel's name
+			name := "#unknown-channel"
+			if c := channels[e.pin.ChannelID]; c != nil {
+				name = "#" + c.Name
+			}
+
+			f = &discordgo.MessageEmbedField{Name: name}
+			size += len(f.Name)
+		} else {
+			line = "\n" + line
+		}
+
+		if len(f.Value)+len(line) > maxEmbedFieldValue || size+len(line) > maxEmbedTotal {
+			continue
+		}
+
+		if !ok {
+			fields[e.pin.ChannelID] = f
+			embed.Fields = append(embed.Fields, f)
+		}
+
+		f.Value += line
+		size += len(line)
+	}
+
+	return embed
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDigestEmbed(t *testing.T) {
+	m := testPinContent().message
+	p := &store.Pin{GuildID: m.GuildID, ChannelID: m.ChannelID, MessageID: m.ID, Message: m}
+	channels := map[string]*discordgo.Channel{m.ChannelID: {ID: m.ChannelID, Name: "general"}}
+	since := time.Date(2024, 2, 23, 9, 0, 0, 0, time.UTC)
+
+	entries := []digestEntry{{pin: p, reactions: 3}, {pin: p, reactions: 1}}
+
+	embed := buildDigestEmbed(discordgo.German, channels, entries, 2, since, since.Add(digestPeriod))
+
+	require.Equal(t, "🗞️ Wöchentlicher Pin-Überblick", embed.Title)
+	require.Equal(t, "2 Pins seit <t:1708678800:D>", embed.Description)
+	require.Len(t, embed.Fields, 1)
+	require.Equal(t, "#general", embed.Fields[0].Name)
+	require.Equal(t, ""+
+		"[Hello, World!](https://discord.com/channels/100/200/300) • 3 Reaktionen\n"+
+		"[Hello, World!](https://discord.com/channels/100/200/300) • 1 Reaktion",
+		embed.Fields[0].Value)
+}
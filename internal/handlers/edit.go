@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+)
+
+// editPinMessages renders each of the pin's messages again from its record, so that they reflect any changes to it.
+// Each pin message keeps the layout it was posted in, so changes to the guild's layout only apply to new pins. The
+// error editing each of the record's targets is returned in the same order, if there was one.
+func (h *Handler) editPinMessages(ctx context.Context, s *discordgo.Session, config *store.GuildConfig, record *store.Pin) []error {
+	errs := make([]error, len(record.Targets))
+
+	channels, err := s.GuildChannels(record.GuildID, discordgo.WithContext(ctx))
+	if err != nil {
+		for n := range errs {
+			errs[n] = fmt.Errorf("get guild channels: %w", err)
+		}
+		return errs
+	}
+
+	sourceChannel, err := getChannel(channels, record.ChannelID)
+	if err != nil {
+		// the source channel may have been deleted, in which case the pin message can still mention it
+		sourceChannel = &discordgo.Channel{ID: record.ChannelID, GuildID: record.GuildID}
+	}
+
+	// the source guild is only needed for mirrors, so it's fetched when the first is found
+	var guild *discordgo.Guild
+
+	for n, t := range record.Targets {
+		c := recordContent(record, sourceChannel)
+		c.rehosted = t.Rehosted
+
+		if t.GuildID != record.GuildID {
+			if guild == nil {
+				if guild, err = s.Guild(record.GuildID, discordgo.WithContext(ctx)); err != nil {
+					errs[n] = fmt.Errorf("get guild: %w", err)
+					continue
+				}
+			}
+			c.guild = guild
+		} else {
+			c.unpinnable = true
+		}
+
+		errs[n] = editPinMessage(ctx, s, t, buildPinMessage(withLayout(config.Template, t.Layout), c))
+	}
+
+	return errs
+}
+
+// withLayout returns the template with the layout of a pin message
+func withLayout(t *store.Template, layout string) *store.Template {
+	r := withDefaults(t)
+
+	r.Layout = layout
+	if r.Layout == "" {
+		r.Layout = store.LayoutEmbed
+	}
+
+	return &r
+}
+
+// editPinMessage replaces the target pin message with the message
+func editPinMessage(ctx context.Context, s *discordgo.Session, t store.Target, m *discordgo.MessageSend) error {
+	embeds := m.Embeds
+	if embeds == nil {
+		embeds = []*discordgo.MessageEmbed{}
+	}
+
+	components := m.Components
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:              t.MessageID,
+		Channel:         t.ChannelID,
+		Embeds:          &embeds,
+		Components:      &components,
+		Flags:           m.Flags,
+		AllowedMentions: m.AllowedMentions,
+	}, discordgo.WithContext(ctx))
+
+	return err
+}
@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/commands"
+	"github.com/elliotwms/pinbot/internal/store"
+)
+
+// maxUploadSize is the largest file Discord accepts in guilds without boosts. Larger exports are split across several
+// files.
+const maxUploadSize = 10 << 20
+
+// exportPin is a pin as it appears in exports
+type exportPin struct {
+	GuildID        string             `json:"guild_id"`
+	ChannelID      string             `json:"channel_id"`
+	MessageID      string             `json:"message_id"`
+	AuthorID       string             `json:"author_id,omitempty"`
+	AuthorUsername string             `json:"author_username"`
+	Content        string             `json:"content"`
+	PostedAt       time.Time          `json:"posted_at"`
+	PinnedByID     string             `json:"pinned_by_id,omitempty"`
+	PinnedAt       time.Time          `json:"pinned_at"`
+	Note           string             `json:"note,omitempty"`
+	Tags           []string           `json:"tags,omitempty"`
+	Attachments    []exportAttachment `json:"attachments,omitempty"`
+	Targets        []store.Target     `json:"targets"`
+}
+
+type exportAttachment struct {
+	Filename    string `json:"filename"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type,omitempty"`
+}
+
+func newExportPin(p *store.Pin) exportPin {
+	m := p.Message
+
+	e := exportPin{
+		GuildID:    p.GuildID,
+		ChannelID:  p.ChannelID,
+		MessageID:  p.MessageID,
+		Content:    m.Content,
+		PostedAt:   m.Timestamp,
+		PinnedByID: p.PinnedByID,
+		PinnedAt:   p.PinnedAt,
+		Note:       p.Note,
+		Tags:       p.Tags,
+		Targets:    p.Targets,
+	}
+
+	if m.Author != nil {
+		e.AuthorID = m.Author.ID
+		e.AuthorUsername = m.Author.Username
+	}
+
+	for _, a := range m.Attachments {
+		e.Attachments = append(e.Attachments, exportAttachment{
+			Filename:    a.Filename,
+			URL:         a.URL,
+			ContentType: a.ContentType,
+		})
+	}
+
+	return e
+}
+
+// exporter encodes pins in an export format. Each file of an export has its own header and footer, so that every file
+// can be read on its own, and its records are joined by the separator.
+type exporter interface {
+	header() ([]byte, error)
+	record(p exportPin) ([]byte, error)
+	separator() []byte
+	footer() []byte
+}
+
+// exporters build the exporter for each format. Exporters are built for each export, as the HTML exporter downloads
+// media with the session's HTTP client.
+var exporters = map[string]func(ctx context.Context, s *discordgo.Session) exporter{
+	commands.FormatJSON: func(context.Context, *discordgo.Session) exporter { return jsonExporter{} },
+	commands.FormatCSV:  func(context.Context, *discordgo.Session) exporter { return csvExporter{} },
+	commands.FormatHTML: func(ctx context.Context, s *discordgo.Session) exporter { return newHTMLExporter(ctx, s.Client) },
+}
+
+func (h *Handler) export(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, o *discordgo.ApplicationCommandInteractionDataOption) error {
+	if !canManageGuild(i) {
+		return respondLocalized(ctx, s, i.Interaction, textExportDenied)
+	}
+
+	format := optionValue(o, commands.OptionFormat)
+	newExporter, ok := exporters[format]
+	if !ok {
+		return fmt.Errorf("unknown export format: %s", format)
+	}
+
+	log := slog.With("guild_id", i.GuildID, "format", format)
+
+	pins, err := h.store.ListPins(ctx, i.GuildID)
+	if err != nil {
+		log.Error("Could not list pins", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	slices.SortFunc(pins, func(a, b *store.Pin) int {
+		return a.Message.Timestamp.Compare(b.Message.Timestamp)
+	})
+
+	chunks, err := chunkExport(newExporter(ctx, s), pins, maxUploadSize)
+	if err != nil {
+		return fmt.Errorf("export pins: %w", err)
+	}
+
+	l := locale(i.Interaction)
+	content := localize(l, textExported, localizeCount(l, textPins, len(pins)))
+	if len(chunks) > 1 {
+		content = localize(l, textExportedFiles, localizeCount(l, textPins, len(pins)), len(chunks))
+	}
+
+	files := exportFiles(format, chunks)
+
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+		Files:   files[:1],
+	}, discordgo.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	// each file is sent in its own message, as the upload limit applies to the message as a whole
+	for _, f := range files[1:] {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Files: []*discordgo.File{f},
+			Flags: discordgo.MessageFlagsEphemeral,
+		}, discordgo.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Info("Exported pins", "pins", len(pins), "files", len(files))
+
+	return nil
+}
+
+// chunkExport encodes the pins into files of at most limit bytes. Each record is encoded once, as encoding HTML
+// records downloads their media, and records which overflow a file are moved to the next.
+func chunkExport(e exporter, pins []*store.Pin, limit int) ([][]byte, error) {
+	header, err := e.header()
+	if err != nil {
+		return nil, err
+	}
+	separator, footer := e.separator(), e.footer()
+
+	var chunks [][]byte
+	chunk := bytes.NewBuffer(slices.Clone(header))
+	empty := true
+
+	for _, p := range pins {
+		r, err := e.record(newExportPin(p))
+		if err != nil {
+			return nil, err
+		}
+
+		if !empty && chunk.Len()+len(separator)+len(r)+len(footer) > limit {
+			chunk.Write(footer)
+			chunks = append(chunks, chunk.Bytes())
+
+			chunk = bytes.NewBuffer(slices.Clone(header))
+			empty = true
+		}
+
+		if !empty {
+			chunk.Write(separator)
+		}
+		chunk.Write(r)
+		empty = false
+	}
+
+	chunk.Write(footer)
+
+	return append(chunks, chunk.Bytes()), nil
+}
+
+func exportFiles(format string, chunks [][]byte) []*discordgo.File {
+	files := make([]*discordgo.File, 0, len(chunks))
+
+	for n, c := range chunks {
+		name := "pins." + format
+		if len(chunks) > 1 {
+			name = fmt.Sprintf("pins-%d-of-%d.%s", n+1, len(chunks), format)
+		}
+
+		files = append(files, &discordgo.File{
+			Name:   name,
+			Reader: bytes.NewReader(c),
+		})
+	}
+
+	return files
+}
+
+// jsonExporter exports pins as a JSON array
+type jsonExporter struct{}
+
+func (jsonExporter) header() ([]byte, error) {
+	return []byte("["), nil
+}
+
+func (jsonExporter) record(p exportPin) ([]byte, error) {
+	bs, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte("\n  "), bs...), nil
+}
+
+func (jsonExporter) separator() []byte {
+	return []byte(",")
+}
+
+func (jsonExporter) footer() []byte {
+	return []byte("\n]\n")
+}
+
+// csvExporter exports pins as CSV with a row per pin. Fields with several values are separated by spaces.
+type csvExporter struct{}
+
+func (csvExporter) header() ([]byte, error) {
+	return csvRow(
+		"guild_id", "channel_id", "message_id", "author_id", "author_username", "content", "posted_at",
+		"pinned_by_id", "pinned_at", "note", "tags", "attachments", "targets",
+	)
+}
+
+func (csvExporter) record(p exportPin) ([]byte, error) {
+	attachments := make([]string, 0, len(p.Attachments))
+	for _, a := range p.Attachments {
+		attachments = append(attachments, a.URL)
+	}
+
+	targets := make([]string, 0, len(p.Targets))
+	for _, t := range p.Targets {
+		targets = append(targets, t.ChannelID+"/"+t.MessageID)
+	}
+
+	return csvRow(
+		p.GuildID, p.ChannelID, p.MessageID, p.AuthorID, p.AuthorUsername, p.Content, p.PostedAt.Format(time.RFC3339),
+		p.PinnedByID, p.PinnedAt.Format(time.RFC3339), p.Note, strings.Join(p.Tags, " "), strings.Join(attachments, " "),
+		strings.Join(targets, " "),
+	)
+}
+
+func (csvExporter) separator() []byte {
+	return nil
+}
+
+func (csvExporter) footer() []byte {
+	return nil
+}
+
+func csvRow(fields ...string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+
+	_ = w.Write(fields)
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
+
+// maxInlineMediaSize is the most media embedded in each pin of an HTML export, leaving room in the file for the rest of
+// the pin once the media is base64 encoded. Media beyond it is linked instead.
+const maxInlineMediaSize = 6 << 20
+
+// htmlExporter exports pins as a self-contained static HTML gallery. Styles are inline, and images and custom emoji
+// are downloaded and embedded as data URIs, as Discord's CDN URLs expire. Media which can't be downloaded, or doesn't
+// fit, is linked instead.
+type htmlExporter struct {
+	ctx    context.Context
+	client *http.Client
+	tmpl   *template.Template
+
+	// emoji are the sources of the custom emoji images, by URL, as emoji are often repeated across pins
+	emoji map[string]string
+
+	// inlined is the size of the media embedded in the current pin
+	inlined int
+}
+
+func newHTMLExporter(ctx context.Context, client *http.Client) *htmlExporter {
+	e := &htmlExporter{
+		ctx:    ctx,
+		client: client,
+		emoji:  map[string]string{},
+	}
+
+	e.tmpl = template.Must(htmlExportRecordTemplate.Clone()).Funcs(template.FuncMap{
+		"emoji":  e.emojiHTML,
+		"inline": e.inline,
+	})
+
+	return e
+}
+
+var htmlExportTemplate = template.Must(template.New("header").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>📌 Pins</title>
+<style>
+body { font-family: sans-serif; background: #313338; color: #dbdee1; margin: 2em; }
+main { display: grid; grid-template-columns: repeat(auto-fill, minmax(320px, 1fr)); gap: 1em; }
+article { background: #2b2d31; border-left: 4px solid #bb0303; border-radius: 4px; padding: 1em; overflow-wrap: anywhere; }
+header { font-weight: bold; margin-bottom: .5em; }
+time, footer { color: #949ba4; font-size: .8em; }
+p { white-space: pre-wrap; }
+img { max-width: 100%; border-radius: 4px; }
+img.emoji { height: 1.375em; vertical-align: bottom; }
+a { color: #00a8fc; }
+</style>
+</head>
+<body>
+<h1>📌 Pins</h1>
+<main>
+`))
+
+// htmlExportRecordTemplate is parsed with placeholder functions, which are replaced by each exporter's own
+var htmlExportRecordTemplate = template.Must(template.New("record").Funcs(template.FuncMap{
+	"emoji":  func(string) template.HTML { return "" },
+	"inline": func(exportAttachment) any { return "" },
+}).Parse(`<article>
+<header>{{ .AuthorUsername }} <time datetime="{{ .PostedAt.Format "2006-01-02T15:04:05Z07:00" }}">{{ .PostedAt.Format "2 Jan 2006 15:04" }}</time></header>
+{{ with .Content }}<p>{{ emoji . }}</p>{{ end }}
+{{ range .Attachments }}{{ if eq (printf "%.6s" .ContentType) "image/" }}<img src="{{ inline . }}" alt="{{ .Filename }}" loading="lazy">{{ else }}<p><a href="{{ .URL }}">{{ .Filename }}</a></p>{{ end }}
+{{ end }}{{ with .Note }}<p>📝 {{ . }}</p>{{ end }}
+<footer><a href="https://discord.com/channels/{{ .GuildID }}/{{ .ChannelID }}/{{ .MessageID }}">Jump to message</a>{{ range .Tags }} • 🏷️ {{ . }}{{ end }}</footer>
+</article>
+`))
+
+// emojiHTML escapes the text and replaces its custom emoji with their images
+func (e *htmlExporter) emojiHTML(text string) template.HTML {
+	var b strings.Builder
+
+	last := 0
+	for _, m := range customEmojiPattern.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(template.HTMLEscapeString(text[last:m[0]]))
+
+		name, id, animated := text[m[4]:m[5]], text[m[6]:m[7]], m[3] > m[2]
+
+		// emoji images are always on Discord's CDN, so can be linked if they can't be embedded
+		u := emojiImageURL(id, animated)
+		src, ok := e.emoji[u]
+		if !ok {
+			src = u
+			if data, ok := e.download(u, maxInlineMediaSize); ok {
+				src = string(data)
+			}
+			e.emoji[u] = src
+		}
+
+		fmt.Fprintf(&b, `<img class="emoji" src="%s" alt=":%s:" title=":%s:">`,
+			template.HTMLEscapeString(src), name, name)
+
+		last = m[1]
+	}
+	b.WriteString(template.HTMLEscapeString(text[last:]))
+
+	return template.HTML(b.String())
+}
+
+// inline returns the attachment as a data URI, or its URL if it doesn't fit in the pin's remaining media allowance.
+// URLs are returned as strings, so that the template sanitises them.
+func (e *htmlExporter) inline(a exportAttachment) any {
+	src, ok := e.download(a.URL, maxInlineMediaSize-e.inlined)
+	if !ok {
+		return a.URL
+	}
+	e.inlined += len(src)
+
+	return src
+}
+
+// download returns the media at the URL as a data URI if it's at most limit bytes once encoded, or false if it isn't
+// or can't be downloaded
+func (e *htmlExporter) download(u string, limit int) (template.URL, bool) {
+	// base64 encodes every 3 bytes as 4
+	data, contentType, err := fetchMedia(e.ctx, e.client, u, limit/4*3)
+	if err != nil {
+		slog.Warn("Could not embed media in export", "url", u, "error", err)
+		return "", false
+	}
+
+	src := "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	if len(src) > limit {
+		slog.Warn("Could not embed media in export", "url", u, "error", errMediaTooLarge)
+		return "", false
+	}
+
+	return template.URL(src), true
+}
+
+func (e *htmlExporter) header() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := htmlExportTemplate.Execute(buf, nil)
+
+	return buf.Bytes(), err
+}
+
+func (e *htmlExporter) record(p exportPin) ([]byte, error) {
+	e.inlined = 0
+
+	buf := &bytes.Buffer{}
+	err := e.tmpl.Execute(buf, p)
+
+	return buf.Bytes(), err
+}
+
+func (e *htmlExporter) separator() []byte {
+	return nil
+}
+
+func (e *htmlExporter) footer() []byte {
+	return []byte("</main>\n</body>\n</html>\n")
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripperFunc serves requests without a network, so that media can be downloaded from any URL
+type roundTripperFunc func(*http.Request) *http.Response
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req), nil
+}
+
+func testMediaClient(media map[string]string) *http.Client {
+	return &http.Client{Transport: roundTripperFunc(func(req *http.Request) *http.Response {
+		body, ok := media[req.URL.String()]
+		if !ok {
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(""))}
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"image/png"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}
+	})}
+}
+
+func TestHTMLExportEmbedsMedia(t *testing.T) {
+	e := newHTMLExporter(context.Background(), testMediaClient(map[string]string{
+		"https://cdn.discordapp.com/attachments/1/2/cheese.png": "cheese",
+		emojiImageURL("3", false):                               "emoji",
+	}))
+
+	r, err := e.record(exportPin{
+		Content: "Hello <:wave:3>",
+		Attachments: []exportAttachment{
+			{Filename: "cheese.png", URL: "https://cdn.discordapp.com/attachments/1/2/cheese.png", ContentType: "image/png"},
+			{Filename: "gone.png", URL: "https://cdn.discordapp.com/attachments/1/2/gone.png", ContentType: "image/png"},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Contains(t, string(r), `<img src="data:image/png;base64,Y2hlZXNl" alt="cheese.png"`)
+	require.Contains(t, string(r), `<img class="emoji" src="data:image/png;base64,ZW1vamk=" alt=":wave:"`)
+	require.Contains(t, string(r), `<img src="https://cdn.discordapp.com/attachments/1/2/gone.png" alt="gone.png"`, "media which can't be downloaded should be linked")
+}
+
+func TestHTMLExportLinksLargeMedia(t *testing.T) {
+	large := strings.Repeat("a", maxInlineMediaSize)
+
+	e := newHTMLExporter(context.Background(), testMediaClient(map[string]string{
+		"https://cdn.discordapp.com/attachments/1/2/large.png": large,
+	}))
+
+	r, err := e.record(exportPin{
+		Attachments: []exportAttachment{
+			{Filename: "large.png", URL: "https://cdn.discordapp.com/attachments/1/2/large.png", ContentType: "image/png"},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Contains(t, string(r), `<img src="https://cdn.discordapp.com/attachments/1/2/large.png"`)
+	require.False(t, bytes.Contains(r, []byte("data:")))
+}
+
+func TestHTMLExportSanitisesLinkedMedia(t *testing.T) {
+	e := newHTMLExporter(context.Background(), testMediaClient(nil))
+
+	r, err := e.record(exportPin{
+		Attachments: []exportAttachment{
+			{Filename: "evil.png", URL: "javascript:alert(1)", ContentType: "image/png"},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Contains(t, string(r), `<img src="#ZgotmplZ" alt="evil.png"`)
+}
+
+func TestChunkExport(t *testing.T) {
+	var pins []*store.Pin
+	for _, id := range []string{"1", "2", "3"} {
+		pins = append(pins, &store.Pin{
+			MessageID: id,
+			Message: &discordgo.Message{
+				ID:        id,
+				Content:   strings.Repeat("a", 100),
+				Timestamp: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+				Attachments: []*discordgo.MessageAttachment{
+					{Filename: id + ".png", URL: "https://cdn.discordapp.com/attachments/1/" + id + ".png", ContentType: "image/png"},
+				},
+			},
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		chunks, err := chunkExport(jsonExporter{}, pins, 800)
+		require.NoError(t, err)
+		require.Len(t, chunks, 2)
+
+		var n int
+		for _, c := range chunks {
+			require.LessOrEqual(t, len(c), 800)
+
+			var records []exportPin
+			require.NoError(t, json.Unmarshal(c, &records), "each file should be valid JSON")
+			n += len(records)
+		}
+		require.Equal(t, 3, n)
+	})
+
+	t.Run("html media is downloaded once", func(t *testing.T) {
+		requests := 0
+		client := &http.Client{Transport: roundTripperFunc(func(*http.Request) *http.Response {
+			requests++
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"image/png"}},
+				Body:       io.NopCloser(strings.NewReader(strings.Repeat("a", 300))),
+			}
+		})}
+
+		chunks, err := chunkExport(newHTMLExporter(context.Background(), client), pins, 2000)
+		require.NoError(t, err)
+		require.Greater(t, len(chunks), 1)
+		require.Equal(t, 3, requests)
+	})
+}
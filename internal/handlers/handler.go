@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/elliotwms/pinbot/internal/store"
+)
+
+// Handler holds the dependencies shared by the interaction handlers
+type Handler struct {
+	store store.Store
+}
+
+func New(s store.Store) *Handler {
+	return &Handler{store: s}
+}
+
+// customID builds a component custom ID which is routed to the handler registered for name, with the args passed to
+// the handler
+func customID(name string, args ...string) string {
+	return strings.Join(append([]string{name}, args...), ":")
+}
@@ -0,0 +1,467 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// text identifies a response in the catalogue. Texts with counts have a form for one and a form for any other number,
+// suffixed with textOne and textOther, and are formatted with localizeCount.
+type text string
+
+const (
+	textOne   = ".one"
+	textOther = ".other"
+)
+
+const (
+	textTemporaryError        text = "temporary_error"
+	textPinNotFound           text = "pin_not_found"
+	textChannelNotFound       text = "channel_not_found"
+	textNoPinsFound           text = "no_pins_found"
+	textInvalidDate           text = "invalid_date"
+	textManagePinbotDenied    text = "manage_pinbot_denied"
+	textExportDenied          text = "export_denied"
+	textRotateDenied          text = "rotate_denied"
+	textUnpinDenied           text = "unpin_denied"
+	textAlreadyPinned         text = "already_pinned"
+	textAlreadyPinnedIn       text = "already_pinned_in"
+	textPinned                text = "pinned"
+	textCouldNotPost          text = "could_not_post"
+	textCouldNotMirror        text = "could_not_mirror"
+	textCouldNotPinNatively   text = "could_not_pin_natively"
+	textChooseChannel         text = "choose_channel"
+	textChannel               text = "channel"
+	textPinWithNote           text = "pin_with_note"
+	textNote                  text = "note"
+	textNotePlaceholder       text = "note_placeholder"
+	textRefreshDisabled       text = "refresh_disabled"
+	textCouldNotFetchOriginal text = "could_not_fetch_original"
+	textRefreshed             text = "refreshed"
+	textCouldNotRefresh       text = "could_not_refresh"
+	textNothingToRefresh      text = "nothing_to_refresh"
+	textPosted                text = "posted"
+	textRotated               text = "rotated"
+	textCouldNotRotate        text = "could_not_rotate"
+	textNothingToRotate       text = "nothing_to_rotate"
+	textTags                  text = "tags"
+	textTagsRemoved           text = "tags_removed"
+	textTagged                text = "tagged"
+	textUnpinned              text = "unpinned"
+	textCouldNotRead          text = "could_not_read"
+	textIndexed               text = "indexed"
+	textSkipped               text = "skipped"
+	textBackfillResume        text = "backfill_resume"
+	textVerified              text = "verified"
+	textExported              text = "exported"
+	textExportedFiles         text = "exported_files"
+	textSearchFound           text = "search_found"
+	textSearchPage            text = "search_page"
+	textSearchNoMatches       text = "search_no_matches"
+	textSearchNarrow          text = "search_narrow"
+	textPrevious              text = "previous"
+	textNext                  text = "next"
+	textStatsEmpty            text = "stats_empty"
+	textStatsAuthors          text = "stats_authors"
+	textStatsPinners          text = "stats_pinners"
+	textStatsChannels         text = "stats_channels"
+	textStatsMonths           text = "stats_months"
+	textStatsNone             text = "stats_none"
+	textDigestTitle           text = "digest_title"
+	textDigestSince           text = "digest_since"
+
+	// texts with counts
+	textPins           text = "pins"
+	textPinbotMessages text = "pinbot_messages"
+	textReactions      text = "reactions"
+)
+
+// fallbackLocale is the locale of responses to interactions from locales without a catalogue, and of the posts of
+// scheduled jobs in guilds whose preferred locale has no catalogue
+const fallbackLocale = discordgo.EnglishUS
+
+// localeAliases are the locales which share the catalogue of another
+var localeAliases = map[discordgo.Locale]discordgo.Locale{
+	discordgo.EnglishGB:    discordgo.EnglishUS,
+	discordgo.SpanishLATAM: discordgo.SpanishES,
+}
+
+// catalogue holds the responses in each supported locale. Every locale has every text, which is checked by the tests.
+var catalogue = map[discordgo.Locale]map[text]string{
+	discordgo.EnglishUS: {
+		textTemporaryError:        "💩 Temporary error, please retry",
+		textPinNotFound:           "🙅 Pin not found",
+		textChannelNotFound:       "🙅 Channel not found, please retry",
+		textNoPinsFound:           "🙅 No pins found",
+		textInvalidDate:           "🙅 Invalid date, please use the format YYYY-MM-DD",
+		textManagePinbotDenied:    "🙅 Only members who can manage the server can manage Pinbot",
+		textExportDenied:          "🙅 Only members who can manage the server can export its pins",
+		textRotateDenied:          "🙅 Only members who can manage the server can rotate its pins",
+		textUnpinDenied:           "🙅 Only the member who pinned this message or a moderator can unpin it",
+		textAlreadyPinned:         "🔄 Message already pinned",
+		textAlreadyPinnedIn:       "🔄 Message already pinned in %s",
+		textPinned:                "📌 Pinned: %s",
+		textCouldNotPost:          "🙅 Could not send pin message. Please ensure bot has permission to post in %s",
+		textCouldNotMirror:        "🙅 Could not mirror pin message to the federated archive",
+		textCouldNotPinNatively:   "🙅 Could not add to the channel's pins. Please ensure bot has permission to pin messages in %s",
+		textChooseChannel:         "📌 Choose a channel to pin to",
+		textChannel:               "Channel",
+		textPinWithNote:           "📌 Pin with note",
+		textNote:                  "Note",
+		textNotePlaceholder:       "Add some context to the pin",
+		textRefreshDisabled:       "🙅 Refreshing pins is not enabled in this server",
+		textCouldNotFetchOriginal: "🙅 Could not fetch the original message, please retry",
+		textRefreshed:             "🔄 Refreshed: %s",
+		textCouldNotRefresh:       "🙅 Could not refresh: %s",
+		textNothingToRefresh:      "🙅 The pin has no pin messages to refresh",
+		textPosted:                "🎲 Posted: %s",
+		textRotated:               "♻️ Rotated %s in %s",
+		textCouldNotRotate:        "🙅 Could not rotate the pins in %s. Please ensure bot has permission to pin messages there",
+		textNothingToRotate:       "♻️ Nothing to rotate, channels are rotated once they reach %d pins",
+		textTags:                  "🏷️ Tags",
+		textTagsRemoved:           "🏷️ Tags removed",
+		textTagged:                "🏷️ Tagged: %s",
+		textUnpinned:              "🗑️ Unpinned",
+		textCouldNotRead:          "🙅 Could not read %s. Please ensure bot has permission to read its history",
+		textIndexed:               "📥 Indexed %s from %s, of which %d were new",
+		textSkipped:               "%s could not be read as pins from this server",
+		textBackfillResume:        "⏭️ Stopped after reading %d messages. Run the command again with `before: %s` to continue",
+		textVerified:              "🔍 Checked %s, found %d with deleted originals",
+		textExported:              "📦 Exported %s",
+		textExportedFiles:         "📦 Exported %s in %d files",
+		textSearchFound:           "🔍 %s found",
+		textSearchPage:            "Page %d of %d",
+		textSearchNoMatches:       "No pins matched your search",
+		textSearchNarrow:          "Narrow your search to see more results",
+		textPrevious:              "Previous",
+		textNext:                  "Next",
+		textStatsEmpty:            "Nothing has been pinned yet",
+		textStatsAuthors:          "Most pinned authors",
+		textStatsPinners:          "Most active pinners",
+		textStatsChannels:         "Busiest channels",
+		textStatsMonths:           "Pins per month",
+		textStatsNone:             "None",
+		textDigestTitle:           "🗞️ Weekly pin digest",
+		textDigestSince:           "%s since <t:%d:D>",
+
+		textPins + textOne:             "%d pin",
+		textPins + textOther:           "%d pins",
+		textPinbotMessages + textOne:   "%d Pinbot message",
+		textPinbotMessages + textOther: "%d Pinbot messages",
+		textReactions + textOne:        "%d reaction",
+		textReactions + textOther:      "%d reactions",
+	},
+	discordgo.German: {
+		textTemporaryError:        "💩 Vorübergehender Fehler, bitte versuche es erneut",
+		textPinNotFound:           "🙅 Pin nicht gefunden",
+		textChannelNotFound:       "🙅 Kanal nicht gefunden, bitte versuche es erneut",
+		textNoPinsFound:           "🙅 Keine Pins gefunden",
+		textInvalidDate:           "🙅 Ungültiges Datum, bitte verwende das Format JJJJ-MM-TT",
+		textManagePinbotDenied:    "🙅 Nur Mitglieder, die den Server verwalten können, können Pinbot verwalten",
+		textExportDenied:          "🙅 Nur Mitglieder, die den Server verwalten können, können seine Pins exportieren",
+		textRotateDenied:          "🙅 Nur Mitglieder, die den Server verwalten können, können seine Pins rotieren",
+		textUnpinDenied:           "🙅 Nur das Mitglied, das diese Nachricht angeheftet hat, oder ein Moderator kann sie lösen",
+		textAlreadyPinned:         "🔄 Nachricht bereits angeheftet",
+		textAlreadyPinnedIn:       "🔄 Nachricht bereits in %s angeheftet",
+		textPinned:                "📌 Angeheftet: %s",
+		textCouldNotPost:          "🙅 Pin-Nachricht konnte nicht gesendet werden. Bitte stelle sicher, dass der Bot in %s schreiben darf",
+		textCouldNotMirror:        "🙅 Pin-Nachricht konnte nicht in das gemeinsame Archiv gespiegelt werden",
+		textCouldNotPinNatively:   "🙅 Konnte nicht zu den Pins des Kanals hinzugefügt werden. Bitte stelle sicher, dass der Bot in %s Nachrichten anheften darf",
+		textChooseChannel:         "📌 Wähle einen Kanal zum Anheften",
+		textChannel:               "Kanal",
+		textPinWithNote:           "📌 Mit Notiz anheften",
+		textNote:                  "Notiz",
+		textNotePlaceholder:       "Füge dem Pin etwas Kontext hinzu",
+		textRefreshDisabled:       "🙅 Das Aktualisieren von Pins ist auf diesem Server nicht aktiviert",
+		textCouldNotFetchOriginal: "🙅 Die ursprüngliche Nachricht konnte nicht abgerufen werden, bitte versuche es erneut",
+		textRefreshed:             "🔄 Aktualisiert: %s",
+		textCouldNotRefresh:       "🙅 Konnte nicht aktualisiert werden: %s",
+		textNothingToRefresh:      "🙅 Der Pin hat keine Pin-Nachrichten zum Aktualisieren",
+		textPosted:                "🎲 Gepostet: %s",
+		textRotated:               "♻️ %s in %s rotiert",
+		textCouldNotRotate:        "🙅 Die Pins in %s konnten nicht rotiert werden. Bitte stelle sicher, dass der Bot dort Nachrichten anheften darf",
+		textNothingToRotate:       "♻️ Nichts zu rotieren, Kanäle werden rotiert, sobald sie %d Pins erreichen",
+		textTags:                  "🏷️ Tags",
+		textTagsRemoved:           "🏷️ Tags entfernt",
+		textTagged:                "🏷️ Getaggt: %s",
+		textUnpinned:              "🗑️ Gelöst",
+		textCouldNotRead:          "🙅 %s konnte nicht gelesen werden. Bitte stelle sicher, dass der Bot den Verlauf lesen darf",
+		textIndexed:               "📥 %s aus %s indexiert, davon %d neu",
+		textSkipped:               "%s konnten nicht als Pins dieses Servers gelesen werden",
+		textBackfillResume:        "⏭️ Nach %d gelesenen Nachrichten angehalten. Führe den Befehl erneut mit `before: %s` aus, um fortzufahren",
+		textVerified:              "🔍 %s geprüft, %d mit gelöschten Originalen gefunden",
+		textExported:              "📦 %s exportiert",
+		textExportedFiles:         "📦 %s in %d Dateien exportiert",
+		textSearchFound:           "🔍 %s gefunden",
+		textSearchPage:            "Seite %d von %d",
+		textSearchNoMatches:       "Keine Pins entsprechen deiner Suche",
+		textSearchNarrow:          "Schränke deine Suche ein, um mehr Ergebnisse zu sehen",
+		textPrevious:              "Zurück",
+		textNext:                  "Weiter",
+		textStatsEmpty:            "Es wurde noch nichts angeheftet",
+		textStatsAuthors:          "Meistangeheftete Autoren",
+		textStatsPinners:          "Aktivste Anhefter",
+		textStatsChannels:         "Aktivste Kanäle",
+		textStatsMonths:           "Pins pro Monat",
+		textStatsNone:             "Keine",
+		textDigestTitle:           "🗞️ Wöchentlicher Pin-Überblick",
+		textDigestSince:           "%s seit <t:%d:D>",
+
+		textPins + textOne:             "%d Pin",
+		textPins + textOther:           "%d Pins",
+		textPinbotMessages + textOne:   "%d Pinbot-Nachricht",
+		textPinbotMessages + textOther: "%d Pinbot-Nachrichten",
+		textReactions + textOne:        "%d Reaktion",
+		textReactions + textOther:      "%d Reaktionen",
+	},
+	discordgo.French: {
+		textTemporaryError:        "💩 Erreur temporaire, veuillez réessayer",
+		textPinNotFound:           "🙅 Épingle introuvable",
+		textChannelNotFound:       "🙅 Salon introuvable, veuillez réessayer",
+		textNoPinsFound:           "🙅 Aucune épingle trouvée",
+		textInvalidDate:           "🙅 Date invalide, veuillez utiliser le format AAAA-MM-JJ",
+		textManagePinbotDenied:    "🙅 Seuls les membres pouvant gérer le serveur peuvent gérer Pinbot",
+		textExportDenied:          "🙅 Seuls les membres pouvant gérer le serveur peuvent exporter ses épingles",
+		textRotateDenied:          "🙅 Seuls les membres pouvant gérer le serveur peuvent faire tourner ses épingles",
+		textUnpinDenied:           "🙅 Seul le membre qui a épinglé ce message ou un modérateur peut le désépingler",
+		textAlreadyPinned:         "🔄 Message déjà épinglé",
+		textAlreadyPinnedIn:       "🔄 Message déjà épinglé dans %s",
+		textPinned:                "📌 Épinglé : %s",
+		textCouldNotPost:          "🙅 Impossible d'envoyer le message épinglé. Vérifiez que le bot a la permission de publier dans %s",
+		textCouldNotMirror:        "🙅 Impossible de copier le message épinglé dans l'archive fédérée",
+		textCouldNotPinNatively:   "🙅 Impossible d'ajouter aux épingles du salon. Vérifiez que le bot a la permission d'épingler des messages dans %s",
+		textChooseChannel:         "📌 Choisissez un salon où épingler",
+		textChannel:               "Salon",
+		textPinWithNote:           "📌 Épingler avec une note",
+		textNote:                  "Note",
+		textNotePlaceholder:       "Ajoutez du contexte à l'épingle",
+		textRefreshDisabled:       "🙅 L'actualisation des épingles n'est pas activée sur ce serveur",
+		textCouldNotFetchOriginal: "🙅 Impossible de récupérer le message d'origine, veuillez réessayer",
+		textRefreshed:             "🔄 Actualisé : %s",
+		textCouldNotRefresh:       "🙅 Impossible d'actualiser : %s",
+		textNothingToRefresh:      "🙅 L'épingle n'a aucun message à actualiser",
+		textPosted:                "🎲 Publié : %s",
+		textRotated:               "♻️ Rotation de %s dans %s",
+		textCouldNotRotate:        "🙅 Impossible de faire tourner les épingles de %s. Vérifiez que le bot a la permission d'y épingler des messages",
+		textNothingToRotate:       "♻️ Rien à faire tourner, les salons sont traités dès qu'ils atteignent %d épingles",
+		textTags:                  "🏷️ Étiquettes",
+		textTagsRemoved:           "🏷️ Étiquettes retirées",
+		textTagged:                "🏷️ Étiqueté : %s",
+		textUnpinned:              "🗑️ Désépinglé",
+		textCouldNotRead:          "🙅 Impossible de lire %s. Vérifiez que le bot a la permission de lire son historique",
+		textIndexed:               "📥 %s indexées depuis %s, dont %d nouvelles",
+		textSkipped:               "%s n'ont pas pu être lus comme des épingles de ce serveur",
+		textBackfillResume:        "⏭️ Arrêt après la lecture de %d messages. Relancez la commande avec `before: %s` pour continuer",
+		textVerified:              "🔍 %s vérifiées, %d avec un original supprimé",
+		textExported:              "📦 %s exportées",
+		textExportedFiles:         "📦 %s exportées en %d fichiers",
+		textSearchFound:           "🔍 %s trouvées",
+		textSearchPage:            "Page %d sur %d",
+		textSearchNoMatches:       "Aucune épingle ne correspond à votre recherche",
+		textSearchNarrow:          "Affinez votre recherche pour voir plus de résultats",
+		textPrevious:              "Précédent",
+		textNext:                  "Suivant",
+		textStatsEmpty:            "Rien n'a encore été épinglé",
+		textStatsAuthors:          "Auteurs les plus épinglés",
+		textStatsPinners:          "Membres épinglant le plus",
+		textStatsChannels:         "Salons les plus actifs",
+		textStatsMonths:           "Épingles par mois",
+		textStatsNone:             "Aucun",
+		textDigestTitle:           "🗞️ Résumé hebdomadaire des épingles",
+		textDigestSince:           "%s depuis le <t:%d:D>",
+
+		textPins + textOne:             "%d épingle",
+		textPins + textOther:           "%d épingles",
+		textPinbotMessages + textOne:   "%d message Pinbot",
+		textPinbotMessages + textOther: "%d messages Pinbot",
+		textReactions + textOne:        "%d réaction",
+		textReactions + textOther:      "%d réactions",
+	},
+	discordgo.SpanishES: {
+		textTemporaryError:        "💩 Error temporal, inténtalo de nuevo",
+		textPinNotFound:           "🙅 Mensaje fijado no encontrado",
+		textChannelNotFound:       "🙅 Canal no encontrado, inténtalo de nuevo",
+		textNoPinsFound:           "🙅 No se encontraron mensajes fijados",
+		textInvalidDate:           "🙅 Fecha no válida, usa el formato AAAA-MM-DD",
+		textManagePinbotDenied:    "🙅 Solo los miembros que pueden gestionar el servidor pueden gestionar Pinbot",
+		textExportDenied:          "🙅 Solo los miembros que pueden gestionar el servidor pueden exportar sus mensajes fijados",
+		textRotateDenied:          "🙅 Solo los miembros que pueden gestionar el servidor pueden rotar sus mensajes fijados",
+		textUnpinDenied:           "🙅 Solo el miembro que fijó este mensaje o un moderador puede desfijarlo",
+		textAlreadyPinned:         "🔄 Mensaje ya fijado",
+		textAlreadyPinnedIn:       "🔄 Mensaje ya fijado en %s",
+		textPinned:                "📌 Fijado: %s",
+		textCouldNotPost:          "🙅 No se pudo enviar el mensaje fijado. Asegúrate de que el bot tenga permiso para publicar en %s",
+		textCouldNotMirror:        "🙅 No se pudo replicar el mensaje fijado en el archivo federado",
+		textCouldNotPinNatively:   "🙅 No se pudo añadir a los mensajes fijados del canal. Asegúrate de que el bot tenga permiso para fijar mensajes en %s",
+		textChooseChannel:         "📌 Elige un canal donde fijar",
+		textChannel:               "Canal",
+		textPinWithNote:           "📌 Fijar con nota",
+		textNote:                  "Nota",
+		textNotePlaceholder:       "Añade contexto al mensaje fijado",
+		textRefreshDisabled:       "🙅 La actualización de mensajes fijados no está activada en este servidor",
+		textCouldNotFetchOriginal: "🙅 No se pudo obtener el mensaje original, inténtalo de nuevo",
+		textRefreshed:             "🔄 Actualizado: %s",
+		textCouldNotRefresh:       "🙅 No se pudo actualizar: %s",
+		textNothingToRefresh:      "🙅 El mensaje fijado no tiene mensajes que actualizar",
+		textPosted:                "🎲 Publicado: %s",
+		textRotated:               "♻️ Se rotaron %s en %s",
+		textCouldNotRotate:        "🙅 No se pudieron rotar los mensajes fijados en %s. Asegúrate de que el bot tenga permiso para fijar mensajes allí",
+		textNothingToRotate:       "♻️ Nada que rotar, los canales se rotan al llegar a %d mensajes fijados",
+		textTags:                  "🏷️ Etiquetas",
+		textTagsRemoved:           "🏷️ Etiquetas eliminadas",
+		textTagged:                "🏷️ Etiquetado: %s",
+		textUnpinned:              "🗑️ Desfijado",
+		textCouldNotRead:          "🙅 No se pudo leer %s. Asegúrate de que el bot tenga permiso para leer su historial",
+		textIndexed:               "📥 Indexados %s de %s, de los cuales %d eran nuevos",
+		textSkipped:               "%s no se pudieron leer como mensajes fijados de este servidor",
+		textBackfillResume:        "⏭️ Se detuvo tras leer %d mensajes. Vuelve a ejecutar el comando con `before: %s` para continuar",
+		textVerified:              "🔍 Revisados %s, %d con el original eliminado",
+		textExported:              "📦 Exportados %s",
+		textExportedFiles:         "📦 Exportados %s en %d archivos",
+		textSearchFound:           "🔍 %s encontrados",
+		textSearchPage:            "Página %d de %d",
+		textSearchNoMatches:       "Ningún mensaje fijado coincide con tu búsqueda",
+		textSearchNarrow:          "Acota tu búsqueda para ver más resultados",
+		textPrevious:              "Anterior",
+		textNext:                  "Siguiente",
+		textStatsEmpty:            "Todavía no se ha fijado nada",
+		textStatsAuthors:          "Autores más fijados",
+		textStatsPinners:          "Miembros que más fijan",
+		textStatsChannels:         "Canales más activos",
+		textStatsMonths:           "Fijados por mes",
+		textStatsNone:             "Ninguno",
+		textDigestTitle:           "🗞️ Resumen semanal de mensajes fijados",
+		textDigestSince:           "%s desde el <t:%d:D>",
+
+		textPins + textOne:             "%d mensaje fijado",
+		textPins + textOther:           "%d mensajes fijados",
+		textPinbotMessages + textOne:   "%d mensaje de Pinbot",
+		textPinbotMessages + textOther: "%d mensajes de Pinbot",
+		textReactions + textOne:        "%d reacción",
+		textReactions + textOther:      "%d reacciones",
+	},
+	discordgo.PortugueseBR: {
+		textTemporaryError:        "💩 Erro temporário, tente novamente",
+		textPinNotFound:           "🙅 Mensagem fixada não encontrada",
+		textChannelNotFound:       "🙅 Canal não encontrado, tente novamente",
+		textNoPinsFound:           "🙅 Nenhuma mensagem fixada encontrada",
+		textInvalidDate:           "🙅 Data inválida, use o formato AAAA-MM-DD",
+		textManagePinbotDenied:    "🙅 Apenas membros que podem gerenciar o servidor podem gerenciar o Pinbot",
+		textExportDenied:          "🙅 Apenas membros que podem gerenciar o servidor podem exportar suas mensagens fixadas",
+		textRotateDenied:          "🙅 Apenas membros que podem gerenciar o servidor podem rotacionar suas mensagens fixadas",
+		textUnpinDenied:           "🙅 Apenas o membro que fixou esta mensagem ou um moderador pode desafixá-la",
+		textAlreadyPinned:         "🔄 Mensagem já fixada",
+		textAlreadyPinnedIn:       "🔄 Mensagem já fixada em %s",
+		textPinned:                "📌 Fixado: %s",
+		textCouldNotPost:          "🙅 Não foi possível enviar a mensagem fixada. Verifique se o bot tem permissão para publicar em %s",
+		textCouldNotMirror:        "🙅 Não foi possível espelhar a mensagem fixada no arquivo federado",
+		textCouldNotPinNatively:   "🙅 Não foi possível adicionar às mensagens fixadas do canal. Verifique se o bot tem permissão para fixar mensagens em %s",
+		textChooseChannel:         "📌 Escolha um canal para fixar",
+		textChannel:               "Canal",
+		textPinWithNote:           "📌 Fixar com nota",
+		textNote:                  "Nota",
+		textNotePlaceholder:       "Adicione contexto à mensagem fixada",
+		textRefreshDisabled:       "🙅 A atualização de mensagens fixadas não está ativada neste servidor",
+		textCouldNotFetchOriginal: "🙅 Não foi possível obter a mensagem original, tente novamente",
+		textRefreshed:             "🔄 Atualizado: %s",
+		textCouldNotRefresh:       "🙅 Não foi possível atualizar: %s",
+		textNothingToRefresh:      "🙅 A mensagem fixada não tem mensagens para atualizar",
+		textPosted:                "🎲 Publicado: %s",
+		textRotated:               "♻️ Rotação de %s em %s",
+		textCouldNotRotate:        "🙅 Não foi possível rotacionar as mensagens fixadas em %s. Verifique se o bot tem permissão para fixar mensagens lá",
+		textNothingToRotate:       "♻️ Nada para rotacionar, os canais são rotacionados ao atingir %d mensagens fixadas",
+		textTags:                  "🏷️ Etiquetas",
+		textTagsRemoved:           "🏷️ Etiquetas removidas",
+		textTagged:                "🏷️ Etiquetado: %s",
+		textUnpinned:              "🗑️ Desafixado",
+		textCouldNotRead:          "🙅 Não foi possível ler %s. Verifique se o bot tem permissão para ler o histórico",
+		textIndexed:               "📥 Indexadas %s de %s, das quais %d eram novas",
+		textSkipped:               "%s não puderam ser lidas como mensagens fixadas deste servidor",
+		textBackfillResume:        "⏭️ Parou após ler %d mensagens. Execute o comando novamente com `before: %s` para continuar",
+		textVerified:              "🔍 Verificadas %s, %d com a original excluída",
+		textExported:              "📦 Exportadas %s",
+		textExportedFiles:         "📦 Exportadas %s em %d arquivos",
+		textSearchFound:           "🔍 %s encontradas",
+		textSearchPage:            "Página %d de %d",
+		textSearchNoMatches:       "Nenhuma mensagem fixada corresponde à sua pesquisa",
+		textSearchNarrow:          "Refine sua pesquisa para ver mais resultados",
+		textPrevious:              "Anterior",
+		textNext:                  "Próxima",
+		textStatsEmpty:            "Nada foi fixado ainda",
+		textStatsAuthors:          "Autores mais fixados",
+		textStatsPinners:          "Membros que mais fixam",
+		textStatsChannels:         "Canais mais ativos",
+		textStatsMonths:           "Fixações por mês",
+		textStatsNone:             "Nenhum",
+		textDigestTitle:           "🗞️ Resumo semanal de mensagens fixadas",
+		textDigestSince:           "%s desde <t:%d:D>",
+
+		textPins + textOne:             "%d mensagem fixada",
+		textPins + textOther:           "%d mensagens fixadas",
+		textPinbotMessages + textOne:   "%d mensagem do Pinbot",
+		textPinbotMessages + textOther: "%d mensagens do Pinbot",
+		textReactions + textOne:        "%d reação",
+		textReactions + textOther:      "%d reações",
+	},
+}
+
+// locale returns the locale to respond to the interaction in: the user's locale if it has a catalogue, otherwise the
+// guild's preferred locale if it has one, otherwise the fallback
+func locale(i *discordgo.Interaction) discordgo.Locale {
+	locales := []discordgo.Locale{i.Locale}
+	if i.GuildLocale != nil {
+		locales = append(locales, *i.GuildLocale)
+	}
+
+	return supportedLocale(locales...)
+}
+
+// guildLocale returns the locale of posts in the guild which aren't responses to a member, such as those of scheduled
+// jobs: the guild's preferred locale if it has a catalogue, otherwise the fallback
+func guildLocale(g *discordgo.Guild) discordgo.Locale {
+	return supportedLocale(discordgo.Locale(g.PreferredLocale))
+}
+
+// supportedLocale returns the first of the locales with a catalogue, or the fallback if none have one
+func supportedLocale(locales ...discordgo.Locale) discordgo.Locale {
+	for _, l := range locales {
+		if alias, ok := localeAliases[l]; ok {
+			l = alias
+		}
+		if _, ok := catalogue[l]; ok {
+			return l
+		}
+	}
+
+	return fallbackLocale
+}
+
+// localize returns the text in the locale, formatted with the args. Locales without a catalogue use the fallback.
+func localize(l discordgo.Locale, t text, args ...any) string {
+	texts, ok := catalogue[l]
+	if !ok {
+		texts = catalogue[fallbackLocale]
+	}
+
+	if len(args) == 0 {
+		return texts[t]
+	}
+
+	return fmt.Sprintf(texts[t], args...)
+}
+
+// localizeCount returns the count of the text in the locale, e.g. "1 pin" or "2 pins"
+func localizeCount(l discordgo.Locale, t text, n int) string {
+	if n == 1 {
+		return localize(l, t+textOne, n)
+	}
+
+	return localize(l, t+textOther, n)
+}
+
+// respondLocalized responds to the interaction with the text in its locale, formatted with the args
+func respondLocalized(ctx context.Context, s *discordgo.Session, i *discordgo.Interaction, t text, args ...any) error {
+	return respond(ctx, s, i, localize(locale(i), t, args...))
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+var verbPattern = regexp.MustCompile(`%[a-z]`)
+
+func TestCatalogueComplete(t *testing.T) {
+	for l, texts := range catalogue {
+		t.Run(string(l), func(t *testing.T) {
+			for key, en := range catalogue[fallbackLocale] {
+				text, ok := texts[key]
+				require.True(t, ok, "missing text %s", key)
+				require.NotEmpty(t, text, "empty text %s", key)
+
+				// translations must take the same arguments in the same order
+				require.Equal(t, verbPattern.FindAllString(en, -1), verbPattern.FindAllString(text, -1), "text %s", key)
+			}
+
+			require.Len(t, texts, len(catalogue[fallbackLocale]))
+		})
+	}
+}
+
+func TestLocale(t *testing.T) {
+	french := discordgo.French
+	japanese := discordgo.Japanese
+
+	tests := map[string]struct {
+		locale      discordgo.Locale
+		guildLocale *discordgo.Locale
+		want        discordgo.Locale
+	}{
+		"user":                  {locale: discordgo.German, guildLocale: &french, want: discordgo.German},
+		"alias":                 {locale: discordgo.SpanishLATAM, want: discordgo.SpanishES},
+		"english alias":         {locale: discordgo.EnglishGB, want: discordgo.EnglishUS},
+		"unsupported user":      {locale: discordgo.Japanese, guildLocale: &french, want: discordgo.French},
+		"unsupported":           {locale: discordgo.Japanese, guildLocale: &japanese, want: fallbackLocale},
+		"unknown without guild": {want: fallbackLocale},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			i := &discordgo.Interaction{Locale: tt.locale, GuildLocale: tt.guildLocale}
+
+			require.Equal(t, tt.want, locale(i))
+		})
+	}
+}
+
+func TestGuildLocale(t *testing.T) {
+	tests := map[string]struct {
+		preferred string
+		want      discordgo.Locale
+	}{
+		"supported":   {preferred: string(discordgo.French), want: discordgo.French},
+		"alias":       {preferred: string(discordgo.SpanishLATAM), want: discordgo.SpanishES},
+		"unsupported": {preferred: string(discordgo.Japanese), want: fallbackLocale},
+		"none":        {want: fallbackLocale},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tt.want, guildLocale(&discordgo.Guild{PreferredLocale: tt.preferred}))
+		})
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	tests := map[discordgo.Locale]string{
+		discordgo.EnglishUS:    "📌 Pinned: https://discord.com/channels/1/2/3",
+		discordgo.German:       "📌 Angeheftet: https://discord.com/channels/1/2/3",
+		discordgo.French:       "📌 Épinglé : https://discord.com/channels/1/2/3",
+		discordgo.SpanishES:    "📌 Fijado: https://discord.com/channels/1/2/3",
+		discordgo.PortugueseBR: "📌 Fixado: https://discord.com/channels/1/2/3",
+		discordgo.Japanese:     "📌 Pinned: https://discord.com/channels/1/2/3",
+	}
+
+	for l, want := range tests {
+		t.Run(string(l), func(t *testing.T) {
+			require.Equal(t, want, localize(l, textPinned, url("1", "2", "3")))
+		})
+	}
+}
+
+func TestLocalizeCount(t *testing.T) {
+	require.Equal(t, "1 pin", localizeCount(discordgo.EnglishUS, textPins, 1))
+	require.Equal(t, "2 pins", localizeCount(discordgo.EnglishUS, textPins, 2))
+	require.Equal(t, "1 épingle", localizeCount(discordgo.French, textPins, 1))
+	require.Equal(t, "3 Pins", localizeCount(discordgo.German, textPins, 3))
+	require.Equal(t, "📦 Exportados 2 mensajes fijados en 3 archivos",
+		localize(discordgo.SpanishES, textExportedFiles, localizeCount(discordgo.SpanishES, textPins, 2), 3))
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// withAuthorMember sets the message's member to its author's member in the guild, so that the pin message can show the
+// author's server nickname and avatar. The member is taken from the message or the resolved data if present, which may
+// be nil, and fetched otherwise. Messages sent by webhooks have no member.
+func withAuthorMember(ctx context.Context, s *discordgo.Session, m *discordgo.Message, resolved *discordgo.ApplicationCommandInteractionDataResolved) error {
+	if m.Author == nil || m.WebhookID != "" {
+		return nil
+	}
+
+	member := m.Member
+	if member == nil && resolved != nil {
+		member = resolved.Members[m.Author.ID]
+	}
+	if member == nil {
+		var err error
+		if member, err = s.GuildMember(m.GuildID, m.Author.ID, discordgo.WithContext(ctx)); err != nil {
+			return fmt.Errorf("get member %s: %w", m.Author.ID, err)
+		}
+	}
+
+	// members in messages and resolved data are partial, without their user or guild
+	member.User = m.Author
+	member.GuildID = m.GuildID
+	m.Member = member
+
+	return nil
+}
+
+// memberName returns the name the member is shown by in the guild: their server nickname, or their display name if
+// they don't have one
+func memberName(m *discordgo.Member) string {
+	if m.Nick != "" {
+		return m.Nick
+	}
+
+	return m.User.DisplayName()
+}
+
+// authorName returns the name the message's author is shown by in the guild
+func authorName(m *discordgo.Message) string {
+	if m.Member != nil && m.Member.User != nil {
+		return memberName(m.Member)
+	}
+
+	return m.Author.DisplayName()
+}
+
+// authorAvatarURL returns the URL of the avatar the message's author has in the guild
+func authorAvatarURL(m *discordgo.Message) string {
+	if m.Member != nil && m.Member.User != nil && m.Member.GuildID != "" {
+		return m.Member.AvatarURL("")
+	}
+
+	return m.Author.AvatarURL("")
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+	"golang.org/x/sync/errgroup"
+)
+
+// mirror posts the pin message to the guild's mirror channel in another guild. Permissions are verified on both
+// sides: the source channel must be visible to everyone in its guild so that private channels aren't leaked, and the
+// mirror's guild must accept pins from the source guild, with the bot able to post in the mirror channel. Returns true
+// if the images were rehosted.
+func (h *Handler) mirror(ctx context.Context, s *discordgo.Session, r *pinRequest, images []rehostedImage) (*discordgo.Message, bool, error) {
+	c := r.config.Mirror
+
+	var guild *discordgo.Guild
+	var channel *discordgo.Channel
+	var config *store.GuildConfig
+	var permissions *permissionResolver
+
+	group := errgroup.Group{}
+	group.Go(func() (err error) {
+		guild, err = s.Guild(r.message.GuildID, discordgo.WithContext(ctx))
+		return
+	})
+	group.Go(func() (err error) {
+		channel, err = s.Channel(c.ChannelID, discordgo.WithContext(ctx))
+		return
+	})
+	group.Go(func() (err error) {
+		config, err = h.store.GetGuildConfig(ctx, c.GuildID)
+		return
+	})
+	group.Go(func() (err error) {
+		// fails if the bot is not a member of the mirror's guild
+		permissions, err = newPermissionResolver(ctx, s, c.GuildID, r.appID)
+		return
+	})
+
+	if err := group.Wait(); err != nil {
+		return nil, false, err
+	}
+
+	everyone := &permissionResolver{guild: guild, member: everyoneMember()}
+	if !everyone.can(r.sourceChannel, discordgo.PermissionViewChannel) {
+		return nil, false, fmt.Errorf("source channel %s is private", r.sourceChannel.ID)
+	}
+
+	if !slices.Contains(config.MirrorSources, guild.ID) {
+		return nil, false, fmt.Errorf("guild %s does not accept mirrored pins from %s", c.GuildID, guild.ID)
+	}
+
+	if channel.GuildID != c.GuildID {
+		return nil, false, fmt.Errorf("channel %s is not in guild %s", channel.ID, c.GuildID)
+	}
+
+	if !permissions.can(channel, permissionsPost) {
+		return nil, false, fmt.Errorf("missing permission to post in channel %s", channel.ID)
+	}
+
+	// the mirror shows the source guild's name and icon
+	content := r.content(time.Now())
+	content.guild = guild
+
+	return sendPinMessage(ctx, s, channel.ID, r.config.Template, content, images)
+}
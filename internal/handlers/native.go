@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+)
+
+// errNoArchivedPins is returned when a channel's native pins are full, but none of them have been archived by Pinbot
+var errNoArchivedPins = errors.New("channel pins are full and none have been archived")
+
+// nativePin pins the message in Discord's own pin list. If the channel's pins are full then the oldest native pin
+// which has been archived by Pinbot is unpinned to make room, so that nothing is lost.
+func (h *Handler) nativePin(ctx context.Context, s *discordgo.Session, m *discordgo.Message) error {
+	err := s.ChannelMessagePin(m.ChannelID, m.ID, discordgo.WithContext(ctx))
+	if restErrorCode(err) != discordgo.ErrCodeMaximumPinsReached {
+		return err
+	}
+
+	pinned, err := s.ChannelMessagesPinned(m.ChannelID, discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("get pinned messages: %w", err)
+	}
+
+	oldest, err := h.oldestArchived(ctx, m.GuildID, pinned)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ChannelMessageUnpin(m.ChannelID, oldest.ID, discordgo.WithContext(ctx)); err != nil {
+		return fmt.Errorf("unpin oldest archived message: %w", err)
+	}
+
+	return s.ChannelMessagePin(m.ChannelID, m.ID, discordgo.WithContext(ctx))
+}
+
+// oldestArchived returns the earliest pinned of the native pins which has been archived by Pinbot. Native pins are
+// listed most recently pinned first.
+func (h *Handler) oldestArchived(ctx context.Context, guildID string, pinned []*discordgo.Message) (*discordgo.Message, error) {
+	for n := len(pinned) - 1; n >= 0; n-- {
+		_, err := h.store.GetPin(ctx, guildID, pinned[n].ID)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return pinned[n], nil
+	}
+
+	return nil, errNoArchivedPins
+}
+
+// restErrorCode returns the Discord error code of the error, or 0 if it isn't a Discord API error
+func restErrorCode(err error) int {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Message == nil {
+		return 0
+	}
+
+	return restErr.Message.Code
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+	"golang.org/x/sync/errgroup"
+)
+
+// JobOnThisDay routes the daily scheduled event which posts the "on this day" pins
+const JobOnThisDay = "on-this-day"
+
+// maxOnThisDayPins is the maximum number of pins posted in a guild each day
+const maxOnThisDayPins = 10
+
+// OnThisDayJob posts the pins from the same date in previous years into the channel configured by each opted-in guild.
+// Dates are compared in UTC.
+func (h *Handler) OnThisDayJob(ctx context.Context, s *discordgo.Session, t time.Time) error {
+	configs, err := h.store.ListGuildConfigs(ctx)
+	if err != nil {
+		return fmt.Errorf("list guild configs: %w", err)
+	}
+
+	group := errgroup.Group{}
+	for _, c := range configs {
+		if c.OnThisDay == nil {
+			continue
+		}
+
+		group.Go(func() error {
+			if err := h.onThisDay(ctx, s, c, t); err != nil {
+				slog.Error("Could not post on this day pins", "guild_id", c.GuildID, "error", err)
+			}
+
+			return nil
+		})
+	}
+
+	return group.Wait()
+}
+
+func (h *Handler) onThisDay(ctx context.Context, s *discordgo.Session, c *store.GuildConfig, t time.Time) error {
+	t = t.UTC()
+
+	pins, err := h.store.ListPins(ctx, c.GuildID)
+	if err != nil {
+		return err
+	}
+
+	pins = slices.DeleteFunc(pins, func(p *store.Pin) bool {
+		posted := p.Message.Timestamp.UTC()
+
+		return posted.Year() >= t.Year() || posted.Month() != t.Month() || posted.Day() != t.Day()
+	})
+
+	log := slog.With("guild_id", c.GuildID, "channel_id", c.OnThisDay.ChannelID)
+
+	if len(pins) == 0 {
+		log.Info("No pins on this day")
+		return nil
+	}
+
+	// the pins are posted publicly, so pins from private channels are left out
+	everyone, err := newChannelViewer(ctx, s, c.GuildID, everyoneMember())
+	if err != nil {
+		return err
+	}
+
+	pins = slices.DeleteFunc(pins, func(p *store.Pin) bool {
+		return !everyone.canView(ctx, p.ChannelID)
+	})
+
+	slices.SortFunc(pins, func(a, b *store.Pin) int {
+		return a.Message.Timestamp.Compare(b.Message.Timestamp)
+	})
+
+	for _, p := range pins[:min(len(pins), maxOnThisDayPins)] {
+		m := buildRecordMessage(c.Template, p)
+
+		heading := fmt.Sprintf("📅 On this day in %d", p.Message.Timestamp.UTC().Year())
+		if m.Flags&discordgo.MessageFlagsIsComponentsV2 != 0 {
+			// messages using components can't also have content
+			m.Components = append([]discordgo.MessageComponent{discordgo.TextDisplay{Content: heading}}, m.Components...)
+		} else {
+			m.Content = heading
+		}
+
+		if _, err := s.ChannelMessageSendComplex(c.OnThisDay.ChannelID, m, discordgo.WithContext(ctx)); err != nil {
+			return err
+		}
+	}
+
+	log.Info("Posted on this day pins", "count", min(len(pins), maxOnThisDayPins))
+
+	return nil
+}
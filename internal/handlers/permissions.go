@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+	"golang.org/x/sync/errgroup"
+)
+
+// permissionsPost are the permissions required to post a pin message in a channel
+const permissionsPost = discordgo.PermissionViewChannel | discordgo.PermissionSendMessages
+
+// permissionResolver resolves a member's permissions in a guild's channels. Interactions only include the bot's
+// permissions for the channel the interaction was sent in, and the state isn't populated in Lambda, so the guild and
+// member are fetched up front.
+type permissionResolver struct {
+	guild  *discordgo.Guild
+	member *discordgo.Member
+}
+
+func newPermissionResolver(ctx context.Context, s *discordgo.Session, guildID, userID string) (*permissionResolver, error) {
+	r := &permissionResolver{}
+
+	group := errgroup.Group{}
+	group.Go(func() (err error) {
+		r.guild, err = s.Guild(guildID, discordgo.WithContext(ctx))
+		return
+	})
+	group.Go(func() (err error) {
+		r.member, err = s.GuildMember(guildID, userID, discordgo.WithContext(ctx))
+		return
+	})
+
+	return r, group.Wait()
+}
+
+// can returns true if the member has all the permissions in the channel
+func (r *permissionResolver) can(c *discordgo.Channel, permissions int64) bool {
+	return r.permissions(c)&permissions == permissions
+}
+
+// permissions computes the member's permissions in the channel, as described in
+// https://discord.com/developers/docs/topics/permissions#permission-overwrites
+func (r *permissionResolver) permissions(c *discordgo.Channel) int64 {
+	if r.guild.OwnerID == r.member.User.ID {
+		return discordgo.PermissionAll
+	}
+
+	var p int64
+	for _, role := range r.guild.Roles {
+		// the @everyone role shares the guild's ID
+		if role.ID == r.guild.ID || slices.Contains(r.member.Roles, role.ID) {
+			p |= role.Permissions
+		}
+	}
+
+	if p&discordgo.PermissionAdministrator != 0 {
+		return discordgo.PermissionAll
+	}
+
+	// apply the @everyone overwrite, then the role overwrites, then the member overwrite
+	var allow, deny int64
+	for _, o := range c.PermissionOverwrites {
+		if o.Type == discordgo.PermissionOverwriteTypeRole && o.ID == r.guild.ID {
+			p = p&^o.Deny | o.Allow
+		}
+	}
+	for _, o := range c.PermissionOverwrites {
+		if o.Type == discordgo.PermissionOverwriteTypeRole && slices.Contains(r.member.Roles, o.ID) {
+			allow |= o.Allow
+			deny |= o.Deny
+		}
+	}
+	p = p&^deny | allow
+	for _, o := range c.PermissionOverwrites {
+		if o.Type == discordgo.PermissionOverwriteTypeMember && o.ID == r.member.User.ID {
+			p = p&^o.Deny | o.Allow
+		}
+	}
+
+	return p
+}
+
+// channelViewer determines which of a guild's channels a member can view
+type channelViewer struct {
+	s           *discordgo.Session
+	permissions *permissionResolver
+
+	// channels are the guild's channels by ID, including its active threads, and archived threads once they have been
+	// fetched. Channels which couldn't be fetched are nil.
+	channels map[string]*discordgo.Channel
+
+	// visible caches whether the member can view each channel by ID
+	visible map[string]bool
+}
+
+// newChannelViewer returns a channelViewer for the member. Members sent with interactions include their roles, so only
+// the guild, its channels and its active threads are fetched.
+func newChannelViewer(ctx context.Context, s *discordgo.Session, guildID string, member *discordgo.Member) (*channelViewer, error) {
+	v := &channelViewer{
+		s:           s,
+		permissions: &permissionResolver{member: member},
+		channels:    make(map[string]*discordgo.Channel),
+		visible:     make(map[string]bool),
+	}
+
+	var channels []*discordgo.Channel
+	var threads *discordgo.ThreadsList
+
+	group := errgroup.Group{}
+	group.Go(func() (err error) {
+		v.permissions.guild, err = s.Guild(guildID, discordgo.WithContext(ctx))
+		return
+	})
+	group.Go(func() (err error) {
+		channels, err = s.GuildChannels(guildID, discordgo.WithContext(ctx))
+		return
+	})
+	group.Go(func() (err error) {
+		threads, err = s.GuildThreadsActive(guildID, discordgo.WithContext(ctx))
+		return
+	})
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	for _, c := range slices.Concat(channels, threads.Threads) {
+		v.channels[c.ID] = c
+	}
+
+	return v, nil
+}
+
+// forMember returns a channelViewer for another member of the same guild, which shares the channels already fetched
+func (v *channelViewer) forMember(member *discordgo.Member) *channelViewer {
+	return &channelViewer{
+		s:           v.s,
+		permissions: &permissionResolver{guild: v.permissions.guild, member: member},
+		channels:    v.channels,
+		visible:     make(map[string]bool),
+	}
+}
+
+// canView returns true if the member can view the channel. Public threads can be viewed by the members who can view
+// their parent channel, while private threads and channels which no longer exist can't be viewed.
+func (v *channelViewer) canView(ctx context.Context, channelID string) bool {
+	visible, ok := v.visible[channelID]
+	if !ok {
+		visible = v.resolve(ctx, channelID)
+		v.visible[channelID] = visible
+	}
+
+	return visible
+}
+
+func (v *channelViewer) resolve(ctx context.Context, channelID string) bool {
+	c, ok := v.channels[channelID]
+	if !ok {
+		// archived threads aren't listed with the guild's channels or active threads, so are fetched as they're needed
+		var err error
+		if c, err = v.s.Channel(channelID, discordgo.WithContext(ctx)); err != nil {
+			c = nil
+		}
+		v.channels[channelID] = c
+	}
+
+	if c != nil && c.IsThread() {
+		if c.Type == discordgo.ChannelTypeGuildPrivateThread {
+			return false
+		}
+
+		c = v.channels[c.ParentID]
+	}
+
+	return c != nil && v.permissions.can(c, discordgo.PermissionViewChannel)
+}
+
+// everyoneMember returns a member without any roles, whose permissions are those of the guild's @everyone role
+func everyoneMember() *discordgo.Member {
+	return &discordgo.Member{User: &discordgo.User{}}
+}
+
+// canManageGuild returns true if the member who sent the interaction can manage the guild
+func canManageGuild(i *discordgo.InteractionCreate) bool {
+	return i.Member != nil && i.Member.Permissions&(discordgo.PermissionManageGuild|discordgo.PermissionAdministrator) != 0
+}
+
+// canManageMessages returns true if the member who sent the interaction can manage messages in its channel
+func canManageMessages(i *discordgo.InteractionCreate) bool {
+	return i.Member != nil && i.Member.Permissions&(discordgo.PermissionManageMessages|discordgo.PermissionAdministrator) != 0
+}
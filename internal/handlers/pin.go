@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -15,10 +19,15 @@ const (
 	pinMessageColor = 0xbb0303
 )
 
-func PinMessageCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) (err error) {
+func (h *Handler) PinMessageCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) (err error) {
 	m := data.Resolved.Messages[data.TargetID]
 	m.GuildID = i.GuildID // guildID is missing from message in resolved context
 
+	return h.pinMessage(ctx, s, i, m, "")
+}
+
+// pinMessage pins the message to the channels it is routed to, with an optional note from the user pinning it
+func (h *Handler) pinMessage(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, m *discordgo.Message, note string) error {
 	log := slog.With("guild_id", i.GuildID, "channel_id", i.ChannelID, "message_id", m.ID)
 
 	log.Debug("Starting pin message")
@@ -26,6 +35,7 @@ func PinMessageCommandHandler(ctx context.Context, s *discordgo.Session, i *disc
 	// API operations are slow, so fanout and execute concurrently
 	var pinned bool
 	var channels []*discordgo.Channel
+	var config *store.GuildConfig
 
 	group := errgroup.Group{}
 	group.Go(func() error {
@@ -44,38 +54,230 @@ func PinMessageCommandHandler(ctx context.Context, s *discordgo.Session, i *disc
 		}
 		return err
 	})
+	group.Go(func() error {
+		var err error
+		config, err = h.store.GetGuildConfig(ctx, i.GuildID)
+		if err != nil {
+			log.Error("Could not get guild config", "error", err)
+		}
+		return err
+	})
 
 	if err := group.Wait(); err != nil {
-		return respond(ctx, s, i.Interaction, "💩 Temporary error, please retry")
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
 	}
 
 	if pinned {
-		return respond(ctx, s, i.Interaction, "🔄 Message already pinned")
+		return respondLocalized(ctx, s, i.Interaction, textAlreadyPinned)
 	}
 
-	sourceChannel, err := getSourceChannel(channels, m.ChannelID)
+	sourceChannel, err := getChannel(channels, m.ChannelID)
 	if err != nil {
 		log.Error("Could not determine source channel", "error", err)
-		return respond(ctx, s, i.Interaction, "💩 Temporary error, please retry")
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	// determine the target pin channels for the message
+	targetChannels, err := getTargetChannels(channels, sourceChannel, config)
+	if err != nil {
+		log.Error("Could not determine target channels", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	outcome, record := h.pin(ctx, s, log, &pinRequest{
+		appID:         i.AppID,
+		config:        config,
+		sourceChannel: sourceChannel,
+		message:       m,
+		pinnedBy:      i.Member,
+		note:          note,
+		targets:       targetChannels,
+		resolved:      resolvedData(i),
+		locale:        locale(i.Interaction),
+	})
+
+	if record != nil && config.NativePins {
+		if err := h.nativePin(ctx, s, m); err != nil {
+			log.Error("Could not pin message natively", "error", err)
+			outcome.failures = append(outcome.failures, localize(locale(i.Interaction), textCouldNotPinNatively, sourceChannel.Mention()))
+		}
+	}
+
+	return respondPinned(ctx, s, i.Interaction, config, outcome, record)
+}
+
+// pinRequest describes a message to be pinned
+type pinRequest struct {
+	// appID is the ID of the application, which is also the bot's user ID
+	appID         string
+	config        *store.GuildConfig
+	sourceChannel *discordgo.Channel
+	message       *discordgo.Message
+	pinnedBy      *discordgo.Member
+	note          string
+	targets       []*discordgo.Channel
+
+	// reactions are the reactions which pinned the message to the starboard, if it was pinned automatically
+	reactions string
+
+	// resolved is the resolved data of the command which pinned the message, if it was pinned by a command
+	resolved *discordgo.ApplicationCommandInteractionDataResolved
+
+	// locale is the locale of the outcome returned by pin
+	locale discordgo.Locale
+}
+
+// content returns the content of the request's pin message, pinned at the given time
+func (r *pinRequest) content(pinnedAt time.Time) *pinContent {
+	c := &pinContent{
+		sourceChannel: r.sourceChannel,
+		message:       r.message,
+		pinnedAt:      pinnedAt,
+		note:          r.note,
+		reactions:     r.reactions,
+		resolved:      r.resolved,
+	}
+
+	// messages pinned automatically have no pinner
+	if r.pinnedBy != nil {
+		c.pinnedBy = r.pinnedBy.User
+		c.pinnedByName = memberName(r.pinnedBy)
+	}
+
+	return c
+}
+
+// pinOutcome describes the outcome of a pin for each of its targets
+type pinOutcome struct {
+	// pinned links to each of the pin messages, or is empty if there were none
+	pinned string
+
+	// failures describe each of the targets which couldn't be pinned to
+	failures []string
+}
+
+// String returns the outcome as a response, with the pin messages before any failures
+func (o *pinOutcome) String() string {
+	lines := o.failures
+	if o.pinned != "" {
+		lines = append([]string{o.pinned}, lines...)
 	}
 
-	// determine the target pin channel for the message
-	targetChannel, err := getTargetChannel(channels, sourceChannel)
+	return strings.Join(lines, "\n")
+}
+
+// pin posts the pin message to each of the request's target channels concurrently, then to the guild's mirror if
+// configured, before marking the message as pinned and recording the pin. It returns the outcome for each target, and
+// the pin record if the message was pinned to any of them.
+func (h *Handler) pin(ctx context.Context, s *discordgo.Session, log *slog.Logger, r *pinRequest) (*pinOutcome, *store.Pin) {
+	m := r.message
+
+	// the message may have been pinned before, in which case add to its existing record
+	record, err := h.store.GetPin(ctx, m.GuildID, m.ID)
 	if err != nil {
-		log.Error("Could not determine target channel", "error", err)
-		return respond(ctx, s, i.Interaction, "💩 Temporary error, please retry")
+		if !errors.Is(err, store.ErrNotFound) {
+			log.Error("Could not get existing pin", "error", err)
+		}
+
+		record = &store.Pin{
+			GuildID:   m.GuildID,
+			ChannelID: m.ChannelID,
+			MessageID: m.ID,
+		}
 	}
-	log = log.With("target_channel_id", targetChannel.ID)
 
-	// build the rich embed pin message
-	pinMessage := buildPinMessage(sourceChannel, m, i.Member.User)
+	pinnedAt := time.Now()
+
+	if err := withAuthorMember(ctx, s, m, r.resolved); err != nil {
+		// the author may have left the guild, in which case the pin shows their global name and avatar
+		log.Warn("Could not get author's member", "error", err)
+	}
 
-	// send the pin message
-	log.Debug("Sending pin message")
-	pin, err := s.ChannelMessageSendComplex(targetChannel.ID, pinMessage, discordgo.WithContext(ctx))
+	// the source message's images are deleted along with it, so copies are uploaded with the pin messages
+	images, err := downloadImages(ctx, s.Client, m)
 	if err != nil {
-		log.Error("Could not send pin message", "error", err)
-		return respond(ctx, s, i.Interaction, "🙅 Could not send pin message. Please ensure bot has permission to post in "+targetChannel.Mention())
+		log.Warn("Could not download images to rehost", "error", err)
+	}
+
+	c := r.content(pinnedAt)
+	c.unpinnable = true
+
+	// send the pin message to each of the target channels concurrently, collecting the results of each
+	pins := make([]*discordgo.Message, len(r.targets))
+	rehosted := make([]bool, len(r.targets))
+	errs := make([]error, len(r.targets))
+
+	group := errgroup.Group{}
+	for n, targetChannel := range r.targets {
+		group.Go(func() error {
+			log := log.With("target_channel_id", targetChannel.ID)
+
+			log.Debug("Sending pin message")
+			pins[n], rehosted[n], errs[n] = sendPinMessage(ctx, s, targetChannel.ID, r.config.Template, c, images)
+			if errs[n] != nil {
+				log.Error("Could not send pin message", "error", errs[n])
+			}
+
+			// errors are reported per-channel, so a failure shouldn't stop the other sends
+			return nil
+		})
+	}
+
+	_ = group.Wait()
+
+	record.Message = m
+	record.PinnedAt = pinnedAt
+	if c.pinnedBy != nil {
+		record.PinnedByID = c.pinnedBy.ID
+		record.PinnedByName = c.pinnedByName
+	}
+	if r.note != "" {
+		record.Note = r.note
+	}
+	if r.reactions != "" {
+		record.Reactions = r.reactions
+	}
+
+	var failures, links []string
+	for n, pin := range pins {
+		if errs[n] != nil {
+			failures = append(failures, localize(r.locale, textCouldNotPost, r.targets[n].Mention()))
+			continue
+		}
+
+		record.Targets = append(record.Targets, store.Target{
+			GuildID:   m.GuildID,
+			ChannelID: pin.ChannelID,
+			MessageID: pin.ID,
+			Rehosted:  rehosted[n],
+			Layout:    withDefaults(r.config.Template).Layout,
+		})
+		links = append(links, url(m.GuildID, pin.ChannelID, pin.ID))
+	}
+
+	if len(links) == 0 {
+		return &pinOutcome{failures: failures}, nil
+	}
+
+	// mirror the pin unless the mirror channel is already one of its targets, e.g. if it has been pinned before
+	if c := r.config.Mirror; c != nil && !record.HasTarget(c.ChannelID) {
+		log := log.With("mirror_guild_id", c.GuildID, "mirror_channel_id", c.ChannelID)
+
+		log.Debug("Sending mirror pin message")
+		mirror, rehosted, err := h.mirror(ctx, s, r, images)
+		if err != nil {
+			log.Error("Could not mirror pin message", "error", err)
+			failures = append(failures, localize(r.locale, textCouldNotMirror))
+		} else {
+			record.Targets = append(record.Targets, store.Target{
+				GuildID:   c.GuildID,
+				ChannelID: mirror.ChannelID,
+				MessageID: mirror.ID,
+				Rehosted:  rehosted,
+				Layout:    withDefaults(r.config.Template).Layout,
+			})
+			links = append(links, url(c.GuildID, mirror.ChannelID, mirror.ID))
+		}
 	}
 
 	// mark the message as done
@@ -83,12 +285,25 @@ func PinMessageCommandHandler(ctx context.Context, s *discordgo.Session, i *disc
 		log.Error("Could not react to message", "error", err)
 	}
 
-	log.Info("Pinned message", "pin_message_id", pin.ID)
+	if err := h.store.PutPin(ctx, record); err != nil {
+		log.Error("Could not record pin", "error", err)
+	}
+
+	log.Info("Pinned message", "targets", len(links), "failures", len(failures))
 
-	return respond(ctx, s, i.Interaction, "📌 Pinned: "+url(i.GuildID, pin.ChannelID, pin.ID))
+	return &pinOutcome{pinned: localize(r.locale, textPinned, strings.Join(links, " ")), failures: failures}, record
 }
 
-func getSourceChannel(channels []*discordgo.Channel, id string) (*discordgo.Channel, error) {
+// resolvedData returns the resolved data of the interaction if it is a command, or nil otherwise
+func resolvedData(i *discordgo.InteractionCreate) *discordgo.ApplicationCommandInteractionDataResolved {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return nil
+	}
+
+	return i.ApplicationCommandData().Resolved
+}
+
+func getChannel(channels []*discordgo.Channel, id string) (*discordgo.Channel, error) {
 	for _, channel := range channels {
 		if channel.ID == id {
 			return channel, nil
@@ -115,70 +330,6 @@ func url(guildID, channelID, messageID string) string {
 	)
 }
 
-func buildPinMessage(sourceChannel *discordgo.Channel, m *discordgo.Message, pinnedBy *discordgo.User) *discordgo.MessageSend {
-	fields := []*discordgo.MessageEmbedField{
-		{
-			Name:   "Channel",
-			Value:  sourceChannel.Mention(),
-			Inline: true,
-		},
-	}
-
-	u := url(sourceChannel.GuildID, m.ChannelID, m.ID)
-	embed := &discordgo.MessageEmbed{
-		Author: &discordgo.MessageEmbedAuthor{
-			Name:    m.Author.Username,
-			IconURL: m.Author.AvatarURL(""),
-			URL:     u,
-		},
-		Title:       "📌 Pinned",
-		Color:       pinMessageColor,
-		Description: m.Content,
-		URL:         u,
-		Timestamp:   m.Timestamp.Format(time.RFC3339),
-	}
-
-	if pinnedBy != nil {
-		fields = append(fields, &discordgo.MessageEmbedField{
-			Name:   "Pinned by",
-			Value:  pinnedBy.Mention(),
-			Inline: true,
-		})
-	}
-
-	embed.Fields = fields
-
-	pinMessage := &discordgo.MessageSend{
-		Embeds: []*discordgo.MessageEmbed{embed},
-	}
-
-	// If there are multiple attachments then add them to separate embeds
-	for i, a := range m.Attachments {
-		if a.Width == 0 || a.Height == 0 {
-			// only embed images
-			continue
-		}
-		e := &discordgo.MessageEmbedImage{URL: a.URL}
-
-		if i == 0 {
-			// add the first image to the existing embed
-			pinMessage.Embeds[0].Image = e
-		} else {
-			// add any other images to their own embed
-			pinMessage.Embeds = append(pinMessage.Embeds, &discordgo.MessageEmbed{
-				Type:  discordgo.EmbedTypeImage,
-				Color: pinMessageColor,
-				Image: e,
-			})
-		}
-	}
-
-	// preserve the existing embeds
-	pinMessage.Embeds = append(pinMessage.Embeds, m.Embeds...)
-
-	return pinMessage
-}
-
 func isAlreadyPinned(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, m *discordgo.Message) (bool, error) {
 	acks, err := s.MessageReactions(m.ChannelID, m.ID, emojiPinned, 0, "", "", discordgo.WithContext(ctx))
 	if err != nil {
@@ -194,6 +345,34 @@ func isAlreadyPinned(ctx context.Context, s *discordgo.Session, i *discordgo.Int
 	return false, nil
 }
 
+// getTargetChannels returns the target pin channels for a given channel. This is the channel selected by
+// getTargetChannel, followed by any of the guild's configured destinations
+func getTargetChannels(channels []*discordgo.Channel, origin *discordgo.Channel, config *store.GuildConfig) ([]*discordgo.Channel, error) {
+	target, err := getTargetChannel(channels, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := []*discordgo.Channel{target}
+
+	for _, id := range config.Destinations {
+		if slices.ContainsFunc(targets, func(c *discordgo.Channel) bool { return c.ID == id }) {
+			continue
+		}
+
+		c, err := getChannel(channels, id)
+		if err != nil {
+			// the destination may have since been deleted
+			slog.Warn("Could not find destination channel", "guild_id", origin.GuildID, "channel_id", id)
+			continue
+		}
+
+		targets = append(targets, c)
+	}
+
+	return targets, nil
+}
+
 // getTargetChannel returns the target pin channel for a given channel #channel in the following order:
 // #channel-pins (a specific pin channel)
 // #pins (a generic pin channel)
@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+)
+
+// defaultTemplate is the appearance of pin messages in guilds which haven't customised it
+var defaultTemplate = store.Template{
+	Layout:    store.LayoutEmbed,
+	Color:     pinMessageColor,
+	Title:     "📌 Pinned",
+	Fields:    []string{store.TemplateFieldChannel, store.TemplateFieldPinnedBy, store.TemplateFieldNote},
+	Timestamp: store.TimestampPosted,
+}
+
+// Names of the pin message fields
+const (
+	fieldChannel   = "Channel"
+	fieldPinnedBy  = "Pinned by"
+	fieldNote      = "Note"
+	fieldStarboard = "Starboard"
+	fieldTags      = "Tags"
+	fieldOriginal  = "Original"
+)
+
+// footerReposted marks pin messages reposted from a pin's record, e.g. by /pins random, which aren't pins themselves
+const footerReposted = "🔁 Reposted"
+
+// maxMediaGalleryItems is the maximum number of items Discord allows in a media gallery
+const maxMediaGalleryItems = 10
+
+// pinContent is the content of a pin message. Pin messages are rendered from their content whenever they are posted or
+// edited, so that they always reflect the pin's record.
+type pinContent struct {
+	sourceChannel *discordgo.Channel
+	message       *discordgo.Message
+	pinnedBy      *discordgo.User
+	pinnedByName  string
+	pinnedAt      time.Time
+	note          string
+
+	// reactions are the reactions which pinned the message to the starboard, e.g. "⭐ 5"
+	reactions string
+	tags      []string
+	syncedAt  time.Time
+	deletedAt time.Time
+
+	// guild is the source guild when the pin message is posted in a mirror
+	guild *discordgo.Guild
+
+	// resolved is the resolved data of the interaction which pinned the message, used to name the users and roles it
+	// mentions. It is nil when the pin message is rendered from its record.
+	resolved *discordgo.ApplicationCommandInteractionDataResolved
+
+	// rehosted references the source message's images by their copies uploaded with the pin message
+	rehosted bool
+
+	// unpinnable shows the button to unpin the message. Only pin messages in the source guild can be unpinned, as the
+	// pin is recorded against it.
+	unpinnable bool
+
+	// repost marks the pin message as a repost of the pin, rather than one of its targets
+	repost bool
+}
+
+// withDefaults returns the template with any zero values replaced by the defaults
+func withDefaults(t *store.Template) store.Template {
+	if t == nil {
+		return defaultTemplate
+	}
+
+	r := *t
+	if r.Layout == "" {
+		r.Layout = defaultTemplate.Layout
+	}
+	if r.Color == 0 {
+		r.Color = defaultTemplate.Color
+	}
+	if r.Title == "" {
+		r.Title = defaultTemplate.Title
+	}
+	if len(r.Fields) == 0 {
+		r.Fields = defaultTemplate.Fields
+	}
+	if r.Timestamp == "" {
+		r.Timestamp = defaultTemplate.Timestamp
+	}
+
+	return r
+}
+
+// buildPinMessage renders the pin message using the guild's template, which may be nil, in the template's layout
+func buildPinMessage(template *store.Template, c *pinContent) *discordgo.MessageSend {
+	t := withDefaults(template)
+
+	if t.Layout == store.LayoutComponents {
+		return buildPinComponents(t, c)
+	}
+
+	return buildPinEmbeds(t, c)
+}
+
+// buildPinEmbeds renders the pin message as a rich embed, followed by an embed for each additional image and any of the
+// source message's own embeds
+func buildPinEmbeds(t store.Template, c *pinContent) *discordgo.MessageSend {
+	m := c.message
+
+	embed := &discordgo.MessageEmbed{
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    authorName(m),
+			IconURL: authorAvatarURL(m),
+		},
+		Title:       t.Title,
+		Color:       t.Color,
+		Description: messageBody(m, c.resolved),
+		Fields:      pinFields(t, c),
+	}
+
+	// jump links no longer lead anywhere once the source message is deleted
+	if c.deletedAt.IsZero() {
+		embed.URL = jumpURL(c)
+		embed.Author.URL = embed.URL
+	}
+
+	if ts := pinTimestamp(t, c); !ts.IsZero() {
+		embed.Timestamp = ts.Format(time.RFC3339)
+	}
+
+	if footer := pinFooter(t, c); footer != "" {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: footer}
+		if c.guild != nil {
+			embed.Footer.IconURL = c.guild.IconURL("")
+		}
+	}
+
+	pinMessage := &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		AllowedMentions: noMentions(),
+	}
+
+	if buttons := pinButtons(c); len(buttons) > 0 {
+		pinMessage.Components = []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}}
+	}
+
+	// If there are multiple images then add them to separate embeds
+	for i, u := range messageImages(m, c.rehosted) {
+		e := &discordgo.MessageEmbedImage{URL: u}
+
+		if i == 0 {
+			// add the first image to the existing embed
+			pinMessage.Embeds[0].Image = e
+		} else {
+			// add any other images to their own embed
+			pinMessage.Embeds = append(pinMessage.Embeds, &discordgo.MessageEmbed{
+				Type:  discordgo.EmbedTypeImage,
+				Color: t.Color,
+				Image: e,
+			})
+		}
+	}
+
+	// preserve the existing embeds
+	pinMessage.Embeds = append(pinMessage.Embeds, m.Embeds...)
+
+	return pinMessage
+}
+
+// buildPinComponents renders the pin message as a container of components, with the images in a media gallery and the
+// pin message's buttons. Messages using components can't also have embeds, so the source message's own
+// embeds aren't preserved.
+func buildPinComponents(t store.Template, c *pinContent) *discordgo.MessageSend {
+	m := c.message
+
+	text := []discordgo.MessageComponent{
+		discordgo.TextDisplay{Content: fmt.Sprintf("### %s\n**%s**", t.Title, authorName(m))},
+	}
+	if body := messageBody(m, c.resolved); body != "" {
+		text = append(text, discordgo.TextDisplay{Content: body})
+	}
+
+	components := []discordgo.MessageComponent{
+		discordgo.Section{
+			Components: text,
+			Accessory:  discordgo.Thumbnail{Media: discordgo.UnfurledMediaItem{URL: authorAvatarURL(m)}},
+		},
+	}
+
+	var details []string
+	for _, f := range pinFields(t, c) {
+		details = append(details, fmt.Sprintf("**%s**: %s", f.Name, f.Value))
+	}
+	if len(details) > 0 {
+		components = append(components, discordgo.TextDisplay{Content: strings.Join(details, "\n")})
+	}
+
+	var items []discordgo.MediaGalleryItem
+	for _, u := range messageImages(m, c.rehosted) {
+		items = append(items, discordgo.MediaGalleryItem{Media: discordgo.UnfurledMediaItem{URL: u}})
+	}
+	for chunk := range slices.Chunk(items, maxMediaGalleryItems) {
+		components = append(components, discordgo.MediaGallery{Items: chunk})
+	}
+
+	var footer []string
+	if f := pinFooter(t, c); f != "" {
+		footer = append(footer, f)
+	}
+	if ts := pinTimestamp(t, c); !ts.IsZero() {
+		footer = append(footer, fmt.Sprintf("<t:%d:f>", ts.Unix()))
+	}
+	if len(footer) > 0 {
+		components = append(components, discordgo.TextDisplay{Content: "-# " + strings.Join(footer, " • ")})
+	}
+
+	if buttons := pinButtons(c); len(buttons) > 0 {
+		components = append(components, discordgo.ActionsRow{Components: buttons})
+	}
+
+	color := t.Color
+
+	return &discordgo.MessageSend{
+		Components: []discordgo.MessageComponent{
+			discordgo.Container{
+				AccentColor: &color,
+				Components:  components,
+			},
+		},
+		Flags:           discordgo.MessageFlagsIsComponentsV2,
+		AllowedMentions: noMentions(),
+	}
+}
+
+// pinFields returns the fields shown on the pin message: those chosen by the template, followed by any which describe
+// the pin's record
+func pinFields(t store.Template, c *pinContent) []*discordgo.MessageEmbedField {
+	var fields []*discordgo.MessageEmbedField
+
+	for _, f := range t.Fields {
+		switch {
+		case f == store.TemplateFieldChannel:
+			value := c.sourceChannel.Mention()
+			if c.guild != nil {
+				// channel mentions don't resolve outside their guild, so link to the channel by name instead
+				value = fmt.Sprintf("[#%s](https://discord.com/channels/%s/%s)", c.sourceChannel.Name, c.guild.ID, c.sourceChannel.ID)
+			}
+
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:   fieldChannel,
+				Value:  value,
+				Inline: true,
+			})
+		case f == store.TemplateFieldPinnedBy && (c.pinnedBy != nil || c.pinnedByName != ""):
+			// pins recorded before names were recorded only have the pinner's ID
+			value := c.pinnedByName
+			if value == "" {
+				value = c.pinnedBy.Mention()
+			}
+
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:   fieldPinnedBy,
+				Value:  value,
+				Inline: true,
+			})
+		case f == store.TemplateFieldNote && c.note != "":
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name:  fieldNote,
+				Value: c.note,
+			})
+		}
+	}
+
+	if c.reactions != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   fieldStarboard,
+			Value:  c.reactions,
+			Inline: true,
+		})
+	}
+
+	if len(c.tags) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  fieldTags,
+			Value: "🏷️ " + strings.Join(c.tags, ", "),
+		})
+	}
+
+	if !c.deletedAt.IsZero() {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  fieldOriginal,
+			Value: fmt.Sprintf("🗑️ Deleted, noticed <t:%d:D>", c.deletedAt.Unix()),
+		})
+	}
+
+	return fields
+}
+
+// pinFooter returns the footer text of the pin message, which may be empty
+func pinFooter(t store.Template, c *pinContent) string {
+	var parts []string
+
+	if c.guild != nil {
+		parts = append(parts, c.guild.Name)
+	}
+	if t.Footer != "" {
+		parts = append(parts, t.Footer)
+	}
+	if !c.syncedAt.IsZero() {
+		parts = append(parts, "Last synced "+c.syncedAt.UTC().Format(syncedFormat))
+	}
+	if c.repost {
+		parts = append(parts, footerReposted)
+	}
+
+	return strings.Join(parts, " • ")
+}
+
+// pinTimestamp returns the time shown on the pin message, which is zero if the template hides it
+func pinTimestamp(t store.Template, c *pinContent) time.Time {
+	switch t.Timestamp {
+	case store.TimestampPosted:
+		return c.message.Timestamp
+	case store.TimestampPinned:
+		return c.pinnedAt
+	}
+
+	return time.Time{}
+}
+
+// pinButtons returns the buttons shown on the pin message: links to jump to the source message and the message it
+// replied to, if any, and a button to unpin it
+func pinButtons(c *pinContent) []discordgo.MessageComponent {
+	m := c.message
+
+	var buttons []discordgo.MessageComponent
+
+	// jump links no longer lead anywhere once the source message is deleted
+	if c.deletedAt.IsZero() {
+		buttons = append(buttons, discordgo.Button{
+			Label: "Jump to message",
+			Style: discordgo.LinkButton,
+			URL:   jumpURL(c),
+		})
+	}
+
+	if ref := m.MessageReference; ref != nil && m.Type == discordgo.MessageTypeReply {
+		buttons = append(buttons, discordgo.Button{
+			Label: "Jump to reply",
+			Style: discordgo.LinkButton,
+			URL:   url(c.sourceChannel.GuildID, ref.ChannelID, ref.MessageID),
+		})
+	}
+
+	if c.unpinnable {
+		buttons = append(buttons, discordgo.Button{
+			Label:    "Unpin",
+			Style:    discordgo.DangerButton,
+			CustomID: customID(ComponentUnpin, m.ID),
+		})
+	}
+
+	return buttons
+}
+
+func jumpURL(c *pinContent) string {
+	return url(c.sourceChannel.GuildID, c.message.ChannelID, c.message.ID)
+}
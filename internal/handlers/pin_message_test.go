@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestBuildPinMessage(t *testing.T) {
+	tests := map[string]*store.Template{
+		"default": nil,
+		"custom": {
+			Color:     0x00ff00,
+			Title:     "⭐ Hall of fame",
+			Fields:    []string{store.TemplateFieldNote, store.TemplateFieldChannel},
+			Footer:    "Pinned with Pinbot",
+			Timestamp: store.TimestampPinned,
+		},
+		"hide_pinner": {
+			Fields: []string{store.TemplateFieldChannel, store.TemplateFieldNote},
+		},
+		"no_timestamp": {
+			Timestamp: store.TimestampNone,
+		},
+	}
+
+	for name, template := range tests {
+		t.Run(name, func(t *testing.T) {
+			assertGolden(t, name, buildPinMessage(template, testPinContent()))
+		})
+	}
+}
+
+func TestBuildPinMessageMirror(t *testing.T) {
+	c := testPinContent()
+	c.guild = &discordgo.Guild{ID: "100", Name: "Source Guild"}
+
+	assertGolden(t, "mirror", buildPinMessage(&store.Template{Footer: "Pinned with Pinbot"}, c))
+}
+
+func TestBuildPinMessageRecord(t *testing.T) {
+	c := testPinContent()
+	c.reactions = "⭐ 5"
+	c.tags = []string{"funny", "lore"}
+	c.syncedAt = time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+	c.deletedAt = time.Date(2024, 3, 3, 11, 0, 0, 0, time.UTC)
+
+	assertGolden(t, "record", buildPinMessage(nil, c))
+}
+
+func TestBuildPinMessageRehosted(t *testing.T) {
+	c := testPinContent()
+	c.rehosted = true
+
+	t.Run("embeds", func(t *testing.T) {
+		assertGolden(t, "rehosted", buildPinMessage(nil, c))
+	})
+
+	t.Run("components", func(t *testing.T) {
+		assertGolden(t, "components_rehosted", buildPinMessage(&store.Template{Layout: store.LayoutComponents}, c))
+	})
+}
+
+func TestBuildPinMessageComponents(t *testing.T) {
+	tests := map[string]func(c *pinContent){
+		"components":         func(*pinContent) {},
+		"components_deleted": func(c *pinContent) { c.deletedAt = time.Date(2024, 3, 3, 11, 0, 0, 0, time.UTC) },
+		"components_no_content": func(c *pinContent) {
+			c.message.Content = ""
+			c.message.Attachments = nil
+		},
+	}
+
+	for name, f := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := testPinContent()
+			f(c)
+
+			assertGolden(t, name, buildPinMessage(&store.Template{Layout: store.LayoutComponents}, c))
+		})
+	}
+}
+
+func TestBuildPinMessageContent(t *testing.T) {
+	tests := map[string]func(m *discordgo.Message){
+		"sticker": func(m *discordgo.Message) {
+			m.Content = ""
+			m.Attachments = nil
+			m.StickerItems = []*discordgo.StickerItem{
+				{ID: "600", Name: "wave", FormatType: discordgo.StickerFormatTypePNG},
+				{ID: "601", Name: "dance", FormatType: discordgo.StickerFormatTypeLottie},
+			}
+		},
+		"poll": func(m *discordgo.Message) {
+			m.Content = "Settle this <:thonk:700>"
+			m.Attachments = nil
+			m.Poll = &discordgo.Poll{
+				Question: discordgo.PollMedia{Text: "Best pizza topping?"},
+				Answers: []discordgo.PollAnswer{
+					{AnswerID: 1, Media: &discordgo.PollMedia{Text: "Pineapple", Emoji: &discordgo.ComponentEmoji{Name: "🍍"}}},
+					{AnswerID: 2, Media: &discordgo.PollMedia{Text: "Mushroom", Emoji: &discordgo.ComponentEmoji{Name: "shroom", ID: "701"}}},
+				},
+				Results: &discordgo.PollResults{
+					Finalized:    true,
+					AnswerCounts: []*discordgo.PollAnswerCount{{ID: 1, Count: 3}, {ID: 2, Count: 1}},
+				},
+			}
+		},
+	}
+
+	for name, f := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := testPinContent()
+			f(c.message)
+
+			assertGolden(t, name, buildPinMessage(nil, c))
+		})
+	}
+}
+
+func TestBuildPinMessageButtons(t *testing.T) {
+	tests := map[string]*store.Template{
+		"buttons":            nil,
+		"components_buttons": {Layout: store.LayoutComponents},
+	}
+
+	for name, template := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := testPinContent()
+			c.message.Type = discordgo.MessageTypeReply
+			c.message.MessageReference = &discordgo.MessageReference{MessageID: "299", ChannelID: "200", GuildID: "100"}
+			c.unpinnable = true
+
+			assertGolden(t, name, buildPinMessage(template, c))
+		})
+	}
+}
+
+func TestBuildPinMessageNicknames(t *testing.T) {
+	c := testPinContent()
+	c.message.Member = &discordgo.Member{
+		GuildID: "100",
+		User:    c.message.Author,
+		Nick:    "The Author",
+		Avatar:  "abc123",
+	}
+	c.pinnedByName = "The Pinner"
+
+	assertGolden(t, "nicknames", buildPinMessage(nil, c))
+}
+
+func TestBuildPinMessageMentions(t *testing.T) {
+	c := testPinContent()
+	c.message.Content = "<@401> <@!402> <@403> <@&800> <@&801> @everyone @here"
+	c.message.Mentions = []*discordgo.User{
+		{ID: "401", Username: "alice", GlobalName: "Alice"},
+		{ID: "402", Username: "bob"},
+	}
+	c.resolved = &discordgo.ApplicationCommandInteractionDataResolved{
+		Members: map[string]*discordgo.Member{"402": {Nick: "Bobby"}},
+		Roles:   map[string]*discordgo.Role{"800": {ID: "800", Name: "mods"}},
+	}
+
+	assertGolden(t, "mentions", buildPinMessage(nil, c))
+}
+
+func testPinContent() *pinContent {
+	return &pinContent{
+		sourceChannel: &discordgo.Channel{ID: "200", GuildID: "100", Name: "general"},
+		message: &discordgo.Message{
+			ID:        "300",
+			ChannelID: "200",
+			GuildID:   "100",
+			Content:   "Hello, World!",
+			Timestamp: time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+			Author:    &discordgo.User{ID: "400", Username: "author"},
+			Attachments: []*discordgo.MessageAttachment{
+				{ID: "1", Filename: "a.png", URL: "https://cdn.discordapp.com/attachments/200/1/a.png", Width: 100, Height: 100},
+				{ID: "2", Filename: "b.png", URL: "https://cdn.discordapp.com/attachments/200/2/b.png", Width: 100, Height: 100},
+			},
+		},
+		pinnedBy: &discordgo.User{ID: "500", Username: "pinner"},
+		pinnedAt: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		note:     "this was after the outage",
+	}
+}
+
+// assertGolden compares the rendered message with the golden file testdata/<name>.golden.json. Run the tests with
+// -update to write the golden files.
+func assertGolden(t *testing.T, name string, m *discordgo.MessageSend) {
+	t.Helper()
+
+	actual, err := json.MarshalIndent(m, "", "  ")
+	require.NoError(t, err)
+
+	path := filepath.Join("testdata", name+".golden.json")
+
+	if *update {
+		require.NoError(t, os.WriteFile(path, append(actual, '\n'), 0o644))
+	}
+
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	require.JSONEq(t, string(expected), string(actual))
+}
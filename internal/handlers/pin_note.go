@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ModalPinNote routes the modal sent in response to the "Pin with note" command
+const ModalPinNote = "pin_note"
+
+const (
+	// inputNote is the custom ID of the note text input
+	inputNote = "note"
+
+	// maxNoteLength is the maximum length of an embed field value
+	maxNoteLength = 1024
+)
+
+// PinWithNoteMessageCommandHandler responds with a modal for the user to annotate the message with a note. The pin is
+// completed by PinWithNoteModalSubmitHandler once the modal has been submitted.
+func (h *Handler) PinWithNoteMessageCommandHandler(_ context.Context, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) (*discordgo.InteractionResponse, error) {
+	m := data.Resolved.Messages[data.TargetID]
+	l := locale(i.Interaction)
+
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: customID(ModalPinNote, m.ChannelID, m.ID),
+			Title:    localize(l, textPinWithNote),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    inputNote,
+						Label:       localize(l, textNote),
+						Style:       discordgo.TextInputParagraph,
+						Placeholder: localize(l, textNotePlaceholder),
+						Required:    true,
+						MaxLength:   maxNoteLength,
+					},
+				}},
+			},
+		},
+	}, nil
+}
+
+// PinWithNoteModalSubmitHandler pins the message with the note submitted in the modal sent by
+// PinWithNoteMessageCommandHandler. The message is identified by the channel and message IDs in the args.
+func (h *Handler) PinWithNoteModalSubmitHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ModalSubmitInteractionData, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("unexpected pin with note modal args: %v", args)
+	}
+
+	// the message isn't resolved for modal interactions, so fetch it
+	m, err := s.ChannelMessage(args[0], args[1], discordgo.WithContext(ctx))
+	if err != nil {
+		slog.Error("Could not get message", "guild_id", i.GuildID, "channel_id", args[0], "message_id", args[1], "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+	m.GuildID = i.GuildID
+
+	return h.pinMessage(ctx, s, i, m, textInputValue(data.Components, inputNote))
+}
+
+// textInputValue returns the value of the text input with the custom ID from the submitted modal components
+func textInputValue(components []discordgo.MessageComponent, id string) string {
+	for _, c := range components {
+		switch c := c.(type) {
+		case *discordgo.ActionsRow:
+			if v := textInputValue(c.Components, id); v != "" {
+				return v
+			}
+		case *discordgo.TextInput:
+			if c.CustomID == id {
+				return c.Value
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+	"golang.org/x/sync/errgroup"
+)
+
+// ComponentPinTo routes the channel select menu sent in response to the "Pin to…" command
+const ComponentPinTo = "pin_to"
+
+// PinToMessageCommandHandler responds with a menu of the text channels the message can be pinned to. The pin is
+// completed by PinToComponentHandler once a channel has been chosen, which checks the bot can post in it.
+func (h *Handler) PinToMessageCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) (err error) {
+	m := data.Resolved.Messages[data.TargetID]
+
+	l := locale(i.Interaction)
+
+	content := localize(l, textChooseChannel)
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			// a channel select menu offers every channel in the guild, where a string select menu is limited to 25
+			discordgo.SelectMenu{
+				MenuType:     discordgo.ChannelSelectMenu,
+				CustomID:     customID(ComponentPinTo, m.ChannelID, m.ID),
+				Placeholder:  localize(l, textChannel),
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+			},
+		}},
+	}
+
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:    &content,
+		Components: &components,
+	}, discordgo.WithContext(ctx))
+
+	return err
+}
+
+// PinToComponentHandler pins the message to the channel chosen from the menu sent by PinToMessageCommandHandler. The
+// message is identified by the channel and message IDs in the args.
+func (h *Handler) PinToComponentHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.MessageComponentInteractionData, args []string) (err error) {
+	if len(args) != 2 || len(data.Values) != 1 {
+		return fmt.Errorf("unexpected pin to component data: %v %v", args, data.Values)
+	}
+	channelID, messageID, targetChannelID := args[0], args[1], data.Values[0]
+
+	log := slog.With("guild_id", i.GuildID, "channel_id", channelID, "message_id", messageID, "target_channel_id", targetChannelID)
+
+	log.Debug("Starting pin message to channel")
+
+	var m *discordgo.Message
+	var channels []*discordgo.Channel
+	var config *store.GuildConfig
+	var permissions *permissionResolver
+
+	group := errgroup.Group{}
+	group.Go(func() error {
+		var err error
+		// the message isn't resolved for component interactions, so fetch it
+		m, err = s.ChannelMessage(channelID, messageID, discordgo.WithContext(ctx))
+		if err != nil {
+			log.Error("Could not get message", "error", err)
+		}
+		return err
+	})
+	group.Go(func() error {
+		var err error
+		channels, err = s.GuildChannels(i.GuildID, discordgo.WithContext(ctx))
+		if err != nil {
+			log.Error("Could not get guild channels", "error", err)
+		}
+		return err
+	})
+	group.Go(func() error {
+		var err error
+		config, err = h.store.GetGuildConfig(ctx, i.GuildID)
+		if err != nil {
+			log.Error("Could not get guild config", "error", err)
+		}
+		return err
+	})
+	group.Go(func() error {
+		var err error
+		permissions, err = newPermissionResolver(ctx, s, i.GuildID, i.AppID)
+		if err != nil {
+			log.Error("Could not get bot permissions", "error", err)
+		}
+		return err
+	})
+
+	if err := group.Wait(); err != nil {
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+	m.GuildID = i.GuildID
+
+	sourceChannel, err := getChannel(channels, m.ChannelID)
+	if err != nil {
+		log.Error("Could not determine source channel", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	targetChannel, err := getChannel(channels, targetChannelID)
+	if err != nil {
+		log.Error("Could not determine target channel", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textChannelNotFound)
+	}
+
+	// the menu offers every text channel in the guild, including those the bot can't post in
+	if targetChannel.Type != discordgo.ChannelTypeGuildText || !permissions.can(targetChannel, permissionsPost) {
+		return respondLocalized(ctx, s, i.Interaction, textCouldNotPost, targetChannel.Mention())
+	}
+
+	if p, err := h.store.GetPin(ctx, i.GuildID, m.ID); err == nil && p.HasTarget(targetChannel.ID) {
+		return respondLocalized(ctx, s, i.Interaction, textAlreadyPinnedIn, targetChannel.Mention())
+	}
+
+	outcome, record := h.pin(ctx, s, log, &pinRequest{
+		appID:         i.AppID,
+		config:        config,
+		sourceChannel: sourceChannel,
+		message:       m,
+		pinnedBy:      i.Member,
+		targets:       []*discordgo.Channel{targetChannel},
+		resolved:      resolvedData(i),
+		locale:        locale(i.Interaction),
+	})
+
+	return respondPinned(ctx, s, i.Interaction, config, outcome, record)
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/commands"
+)
+
+// PinsCommandHandler handles the /pins command, dispatching to the handler for its subcommand
+func (h *Handler) PinsCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) (err error) {
+	if len(data.Options) != 1 {
+		return fmt.Errorf("unexpected pins command options: %d", len(data.Options))
+	}
+
+	o := data.Options[0]
+
+	switch o.Name {
+	case commands.PinsSearch:
+		return h.search(ctx, s, i, o)
+	case commands.PinsRandom:
+		return h.random(ctx, s, i, o)
+	case commands.PinsStats:
+		return h.stats(ctx, s, i, o)
+	case commands.PinsExport:
+		return h.export(ctx, s, i, o)
+	case commands.PinsRotate:
+		return h.rotateCommand(ctx, s, i, o)
+	default:
+		return fmt.Errorf("unknown pins subcommand: %s", o.Name)
+	}
+}
+
+// optionValue returns the value of the subcommand's option as a string, or an empty string if it wasn't provided.
+// Options which refer to an entity (e.g. users and channels) have the entity's ID as their value.
+func optionValue(o *discordgo.ApplicationCommandInteractionDataOption, name string) string {
+	opt := o.GetOption(name)
+	if opt == nil || opt.Value == nil {
+		return ""
+	}
+
+	return fmt.Sprint(opt.Value)
+}
+
+// optionInt returns the value of the subcommand's integer option, or 0 if it wasn't provided
+func optionInt(o *discordgo.ApplicationCommandInteractionDataOption, name string) int {
+	opt := o.GetOption(name)
+	if opt == nil || opt.Value == nil {
+		return 0
+	}
+
+	return int(opt.IntValue())
+}
+
+// optionBool returns the value of the subcommand's boolean option, or false if it wasn't provided
+func optionBool(o *discordgo.ApplicationCommandInteractionDataOption, name string) bool {
+	opt := o.GetOption(name)
+	if opt == nil || opt.Value == nil {
+		return false
+	}
+
+	return opt.BoolValue()
+}
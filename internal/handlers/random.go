@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/commands"
+	"github.com/elliotwms/pinbot/internal/store"
+	"golang.org/x/sync/errgroup"
+)
+
+// random posts a random pin from the archive in the channel the command was sent in, re-rendered from its record. As
+// the pin is posted publicly, it is chosen from the channels which both the member and @everyone can view, as well as
+// the channel the command was sent in.
+func (h *Handler) random(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, o *discordgo.ApplicationCommandInteractionDataOption) error {
+	log := slog.With("guild_id", i.GuildID, "channel_id", i.ChannelID)
+
+	var pins []*store.Pin
+	var config *store.GuildConfig
+	var viewer *channelViewer
+
+	group := errgroup.Group{}
+	group.Go(func() (err error) {
+		pins, err = store.Search(ctx, h.store, i.GuildID, store.Query{
+			ChannelID: optionValue(o, commands.OptionChannel),
+			AuthorID:  optionValue(o, commands.OptionAuthor),
+		})
+		return
+	})
+	group.Go(func() (err error) {
+		config, err = h.store.GetGuildConfig(ctx, i.GuildID)
+		return
+	})
+	group.Go(func() (err error) {
+		viewer, err = newChannelViewer(ctx, s, i.GuildID, i.Member)
+		return
+	})
+
+	if err := group.Wait(); err != nil {
+		log.Error("Could not search pins", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	everyone := viewer.forMember(everyoneMember())
+	pins = slices.DeleteFunc(pins, func(p *store.Pin) bool {
+		return !viewer.canView(ctx, p.ChannelID) || (p.ChannelID != i.ChannelID && !everyone.canView(ctx, p.ChannelID))
+	})
+
+	if days := optionInt(o, commands.OptionExcludeDays); days > 0 {
+		since := time.Now().AddDate(0, 0, -days)
+		pins = slices.DeleteFunc(pins, func(p *store.Pin) bool {
+			return p.PinnedAt.After(since)
+		})
+	}
+
+	if len(pins) == 0 {
+		return respondLocalized(ctx, s, i.Interaction, textNoPinsFound)
+	}
+
+	p := pins[rand.IntN(len(pins))]
+	log = log.With("message_id", p.MessageID)
+
+	m, err := s.ChannelMessageSendComplex(i.ChannelID, buildRecordMessage(config.Template, p), discordgo.WithContext(ctx))
+	if err != nil {
+		log.Error("Could not send random pin message", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textCouldNotPost, "<#"+i.ChannelID+">")
+	}
+
+	log.Info("Posted random pin", "pin_message_id", m.ID)
+
+	return respondLocalized(ctx, s, i.Interaction, textPosted, url(i.GuildID, m.ChannelID, m.ID))
+}
+
+// buildRecordMessage rebuilds the pin message from the pin's record to be reposted, marking it as a repost so that it
+// isn't backfilled as one of the pin's targets
+func buildRecordMessage(template *store.Template, p *store.Pin) *discordgo.MessageSend {
+	c := recordContent(p, &discordgo.Channel{ID: p.ChannelID, GuildID: p.GuildID})
+	c.repost = true
+
+	return buildPinMessage(template, c)
+}
+
+// recordContent returns the content of the pin's record, to be posted in a pin message
+func recordContent(p *store.Pin, sourceChannel *discordgo.Channel) *pinContent {
+	m := p.Message
+	m.GuildID = p.GuildID
+
+	var pinnedBy *discordgo.User
+	if p.PinnedByID != "" {
+		pinnedBy = &discordgo.User{ID: p.PinnedByID}
+	}
+
+	return &pinContent{
+		sourceChannel: sourceChannel,
+		message:       m,
+		pinnedBy:      pinnedBy,
+		pinnedByName:  p.PinnedByName,
+		pinnedAt:      p.PinnedAt,
+		note:          p.Note,
+		reactions:     p.Reactions,
+		tags:          p.Tags,
+		syncedAt:      p.SyncedAt,
+		deletedAt:     p.DeletedAt,
+	}
+}
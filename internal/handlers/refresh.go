@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+)
+
+const syncedFormat = "2006-01-02 15:04 MST"
+
+// RefreshPinMessageCommandHandler refetches the source message of a pin and rebuilds its pin messages in place, so
+// that edits to the source message reach the pins. The command can be used on either the source message or one of its
+// pin messages.
+func (h *Handler) RefreshPinMessageCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) (err error) {
+	m := data.Resolved.Messages[data.TargetID]
+
+	log := slog.With("guild_id", i.GuildID, "channel_id", i.ChannelID, "message_id", m.ID)
+
+	config, err := h.store.GetGuildConfig(ctx, i.GuildID)
+	if err != nil {
+		log.Error("Could not get guild config", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	if !config.Refresh {
+		return respondLocalized(ctx, s, i.Interaction, textRefreshDisabled)
+	}
+
+	record, err := h.findPin(ctx, i, m, withDefaults(config.Template).Title)
+	if errors.Is(err, store.ErrNotFound) {
+		return respondLocalized(ctx, s, i.Interaction, textPinNotFound)
+	}
+	if err != nil {
+		log.Error("Could not get pin", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	log = log.With("source_channel_id", record.ChannelID, "source_message_id", record.MessageID)
+
+	source, err := s.ChannelMessage(record.ChannelID, record.MessageID, discordgo.WithContext(ctx))
+	if err != nil {
+		log.Error("Could not get source message", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textCouldNotFetchOriginal)
+	}
+
+	// the author's nickname and avatar may also have changed
+	source.GuildID = record.GuildID
+	if err := withAuthorMember(ctx, s, source, nil); err != nil {
+		log.Warn("Could not get author's member", "error", err)
+	}
+
+	record.Message = source
+	record.SyncedAt = time.Now()
+
+	if err := h.store.PutPin(ctx, record); err != nil {
+		log.Error("Could not record refreshed pin", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	var links []string
+	var failures []string
+	for n, err := range h.editPinMessages(ctx, s, config, record) {
+		t := record.Targets[n]
+		if err != nil {
+			log.Error("Could not refresh pin message", "pin_channel_id", t.ChannelID, "pin_message_id", t.MessageID, "error", err)
+			failures = append(failures, url(t.GuildID, t.ChannelID, t.MessageID))
+			continue
+		}
+
+		links = append(links, url(t.GuildID, t.ChannelID, t.MessageID))
+	}
+
+	l := locale(i.Interaction)
+
+	var lines []string
+	if len(links) > 0 {
+		lines = append(lines, localize(l, textRefreshed, strings.Join(links, " ")))
+	}
+	if len(failures) > 0 {
+		lines = append(lines, localize(l, textCouldNotRefresh, strings.Join(failures, " ")))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, localize(l, textNothingToRefresh))
+	}
+
+	return respond(ctx, s, i.Interaction, strings.Join(lines, "\n"))
+}
+
+// findPin returns the pin record for the message, which may be either a source message or a pin message with the title.
+// Pin messages are traced back to their source message by their unpin button, or by their embed if they don't have one.
+func (h *Handler) findPin(ctx context.Context, i *discordgo.InteractionCreate, m *discordgo.Message, title string) (*store.Pin, error) {
+	if m.Author != nil && m.Author.ID == i.AppID {
+		if id := unpinButtonMessageID(m.Components); id != "" {
+			return h.store.GetPin(ctx, i.GuildID, id)
+		}
+
+		if p, err := parsePinMessage(m, title); err == nil {
+			return h.store.GetPin(ctx, i.GuildID, p.MessageID)
+		}
+	}
+
+	return h.store.GetPin(ctx, i.GuildID, m.ID)
+}
+
+// unpinButtonMessageID returns the ID of the source message unpinned by the pin message's unpin button, in either
+// layout, or an empty string if it doesn't have one
+func unpinButtonMessageID(components []discordgo.MessageComponent) string {
+	for _, c := range components {
+		switch c := c.(type) {
+		case *discordgo.ActionsRow:
+			if id := unpinButtonMessageID(c.Components); id != "" {
+				return id
+			}
+		case *discordgo.Container:
+			if id := unpinButtonMessageID(c.Components); id != "" {
+				return id
+			}
+		case *discordgo.Button:
+			if name, id, ok := strings.Cut(c.CustomID, ":"); ok && name == ComponentUnpin {
+				return id
+			}
+		}
+	}
+
+	return ""
+}
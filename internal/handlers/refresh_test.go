@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnpinButtonMessageID(t *testing.T) {
+	tests := map[string]struct {
+		layout     string
+		unpinnable bool
+		expected   string
+	}{
+		"embed":      {layout: store.LayoutEmbed, unpinnable: true, expected: "300"},
+		"components": {layout: store.LayoutComponents, unpinnable: true, expected: "300"},
+		"mirror":     {layout: store.LayoutComponents, unpinnable: false, expected: ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := testPinContent()
+			c.unpinnable = tt.unpinnable
+
+			// components are decoded from the message as Discord sends it
+			bs, err := json.Marshal(buildPinMessage(&store.Template{Layout: tt.layout}, c))
+			require.NoError(t, err)
+
+			m := &discordgo.Message{}
+			require.NoError(t, json.Unmarshal(bs, m))
+
+			require.Equal(t, tt.expected, unpinButtonMessageID(m.Components))
+		})
+	}
+}
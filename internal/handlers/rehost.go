@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+)
+
+// maxRehostSize is the most image data uploaded with each pin message, leaving room in the upload for the rest of the
+// message
+const maxRehostSize = maxUploadSize - 1<<20
+
+var errMediaTooLarge = errors.New("media too large")
+
+// rehostedImage is a copy of an image attached to the source message. Copies are uploaded with the pin messages, as
+// Discord deletes a message's attachments along with it.
+type rehostedImage struct {
+	name        string
+	contentType string
+	data        []byte
+}
+
+// rehostName is the name of the attachment's copy on pin messages. Names are taken from the attachment's ID, as
+// Discord replaces characters it doesn't allow in filenames, which would break references to the copy.
+func rehostName(a *discordgo.MessageAttachment) string {
+	return a.ID + path.Ext(a.Filename)
+}
+
+// isImage returns true if the attachment is an image, which only images have dimensions for
+func isImage(a *discordgo.MessageAttachment) bool {
+	return a.Width != 0 && a.Height != 0
+}
+
+// downloadImages downloads the images attached to the message so that they can be rehosted. The images are only
+// rehosted if all of them can be, so that none of the pin message's images depend on the source message.
+func downloadImages(ctx context.Context, client *http.Client, m *discordgo.Message) ([]rehostedImage, error) {
+	var images []rehostedImage
+
+	remaining := maxRehostSize
+	for _, a := range m.Attachments {
+		if !isImage(a) {
+			continue
+		}
+
+		data, contentType, err := fetchMedia(ctx, client, a.URL, remaining)
+		if err != nil {
+			return nil, fmt.Errorf("download attachment %s: %w", a.ID, err)
+		}
+		remaining -= len(data)
+
+		images = append(images, rehostedImage{name: rehostName(a), contentType: contentType, data: data})
+	}
+
+	return images, nil
+}
+
+// rehostFiles returns the images as files to upload. Each message needs its own files, as uploading reads them.
+func rehostFiles(images []rehostedImage) []*discordgo.File {
+	files := make([]*discordgo.File, 0, len(images))
+	for _, img := range images {
+		files = append(files, &discordgo.File{
+			Name:        img.name,
+			ContentType: img.contentType,
+			Reader:      bytes.NewReader(img.data),
+		})
+	}
+
+	return files
+}
+
+// sendPinMessage renders and sends the pin message to the channel, uploading the rehosted images with it if there are
+// any. If they can't be uploaded, e.g. as the bot can't attach files in the channel, the pin message is sent linking
+// to the source message's images instead. Returns true if the images were rehosted.
+func sendPinMessage(ctx context.Context, s *discordgo.Session, channelID string, template *store.Template, c *pinContent, images []rehostedImage) (*discordgo.Message, bool, error) {
+	if len(images) > 0 {
+		rehosted := *c
+		rehosted.rehosted = true
+
+		pinMessage := buildPinMessage(template, &rehosted)
+		pinMessage.Files = rehostFiles(images)
+
+		m, err := s.ChannelMessageSendComplex(channelID, pinMessage, discordgo.WithContext(ctx))
+		if err == nil {
+			return m, true, nil
+		}
+
+		slog.Warn("Could not send pin message with rehosted images", "target_channel_id", channelID, "error", err)
+	}
+
+	m, err := s.ChannelMessageSendComplex(channelID, buildPinMessage(template, c), discordgo.WithContext(ctx))
+
+	return m, false, err
+}
+
+// fetchMedia downloads the media at the URL, returning its content and content type. Media larger than limit bytes
+// returns errMediaTooLarge.
+func fetchMedia(ctx context.Context, client *http.Client, u string, limit int) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status: %d", res.StatusCode)
+	}
+
+	// read one byte beyond the limit to detect media which exceeds it
+	data, err := io.ReadAll(io.LimitReader(res.Body, int64(limit)+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > limit {
+		return nil, "", errMediaTooLarge
+	}
+
+	contentType := res.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	return data, contentType, nil
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+)
+
+// respondPinned responds with the outcome of a pin in the guild's response mode. Failures are only ever shown to the
+// member who pinned the message, as is the menu to tag the pin with if the guild has tags configured.
+func respondPinned(ctx context.Context, s *discordgo.Session, i *discordgo.Interaction, config *store.GuildConfig, outcome *pinOutcome, record *store.Pin) error {
+	if record == nil {
+		return respond(ctx, s, i, outcome.String())
+	}
+
+	var components []discordgo.MessageComponent
+	if len(config.Tags) > 0 {
+		components = tagComponents(locale(i), config.Tags, record)
+	}
+
+	switch config.ResponseMode {
+	case store.ResponseModePublic:
+		// the deferred response is ephemeral, so the confirmation is sent as a public followup. The deferred response
+		// must be resolved first, or the followup would replace it and inherit its visibility.
+		if err := respondPrivately(ctx, s, i, strings.Join(outcome.failures, "\n"), components); err != nil {
+			return err
+		}
+
+		_, err := s.FollowupMessageCreate(i, true, &discordgo.WebhookParams{
+			Content:         outcome.pinned,
+			AllowedMentions: noMentions(),
+		}, discordgo.WithContext(ctx))
+
+		return err
+	case store.ResponseModeSilent:
+		// the 📌 reaction on the message is the confirmation
+		return respondPrivately(ctx, s, i, strings.Join(outcome.failures, "\n"), components)
+	default:
+		return respondPrivately(ctx, s, i, outcome.String(), components)
+	}
+}
+
+// respondPrivately edits the ephemeral deferred response with the content and components, or deletes it if there are
+// neither
+func respondPrivately(ctx context.Context, s *discordgo.Session, i *discordgo.Interaction, content string, components []discordgo.MessageComponent) error {
+	if content == "" && len(components) == 0 {
+		return s.InteractionResponseDelete(i, discordgo.WithContext(ctx))
+	}
+
+	edit := &discordgo.WebhookEdit{Content: &content}
+	if len(components) > 0 {
+		edit.Components = &components
+	}
+
+	_, err := s.InteractionResponseEdit(i, edit, discordgo.WithContext(ctx))
+
+	return err
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/commands"
+	"github.com/elliotwms/pinbot/internal/store"
+	"golang.org/x/sync/errgroup"
+)
+
+// JobRotate routes the daily scheduled event which rotates the native pins of opted-in guilds
+const JobRotate = "rotate"
+
+const (
+	// rotateThreshold is the number of native pins at which a channel is rotated, short of Discord's limit of 50
+	rotateThreshold = 45
+
+	// rotateKeep is the number of native pins left in a channel once it has been rotated
+	rotateKeep = 40
+)
+
+// rotation is the outcome of rotating a channel
+type rotation struct {
+	channel *discordgo.Channel
+	rotated int
+	err     error
+}
+
+func (h *Handler) rotateCommand(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, o *discordgo.ApplicationCommandInteractionDataOption) error {
+	if !canManageGuild(i) {
+		return respondLocalized(ctx, s, i.Interaction, textRotateDenied)
+	}
+
+	log := slog.With("guild_id", i.GuildID)
+
+	var channels []*discordgo.Channel
+	var config *store.GuildConfig
+
+	group := errgroup.Group{}
+	group.Go(func() (err error) {
+		channels, err = s.GuildChannels(i.GuildID, discordgo.WithContext(ctx))
+		return
+	})
+	group.Go(func() (err error) {
+		config, err = h.store.GetGuildConfig(ctx, i.GuildID)
+		return
+	})
+
+	if err := group.Wait(); err != nil {
+		log.Error("Could not get guild", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	selected := channels
+	if id := optionValue(o, commands.OptionChannel); id != "" {
+		c, err := getChannel(channels, id)
+		if err != nil {
+			return respondLocalized(ctx, s, i.Interaction, textChannelNotFound)
+		}
+		selected = []*discordgo.Channel{c}
+	}
+
+	l := locale(i.Interaction)
+
+	var lines []string
+	for _, r := range h.rotate(ctx, s, i.AppID, config, channels, selected) {
+		if r.rotated > 0 {
+			lines = append(lines, localize(l, textRotated, localizeCount(l, textPins, r.rotated), r.channel.Mention()))
+		}
+		if r.err != nil {
+			lines = append(lines, localize(l, textCouldNotRotate, r.channel.Mention()))
+		}
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, localize(l, textNothingToRotate, rotateThreshold))
+	}
+
+	return respond(ctx, s, i.Interaction, strings.Join(lines, "\n"))
+}
+
+// RotateJob rotates the native pins of every channel in each opted-in guild
+func (h *Handler) RotateJob(ctx context.Context, s *discordgo.Session, _ time.Time) error {
+	configs, err := h.store.ListGuildConfigs(ctx)
+	if err != nil {
+		return fmt.Errorf("list guild configs: %w", err)
+	}
+
+	bot, err := s.User("@me", discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("get bot user: %w", err)
+	}
+
+	for _, c := range configs {
+		if !c.Rotate {
+			continue
+		}
+
+		channels, err := s.GuildChannels(c.GuildID, discordgo.WithContext(ctx))
+		if err != nil {
+			slog.Error("Could not get guild channels", "guild_id", c.GuildID, "error", err)
+			continue
+		}
+
+		for _, r := range h.rotate(ctx, s, bot.ID, c, channels, channels) {
+			if r.rotated > 0 || r.err != nil {
+				slog.Info("Rotated channel pins", "guild_id", c.GuildID, "channel_id", r.channel.ID, "rotated", r.rotated, "error", r.err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// rotate rotates each of the selected text channels in turn
+func (h *Handler) rotate(ctx context.Context, s *discordgo.Session, appID string, config *store.GuildConfig, channels, selected []*discordgo.Channel) []rotation {
+	var rotations []rotation
+
+	for _, c := range selected {
+		if c.Type != discordgo.ChannelTypeGuildText {
+			continue
+		}
+
+		n, err := h.rotateChannel(ctx, s, appID, config, channels, c)
+		rotations = append(rotations, rotation{channel: c, rotated: n, err: err})
+	}
+
+	return rotations
+}
+
+// rotateChannel archives the oldest native pins of a channel nearing the pin limit through the usual pin path, then
+// unpins them, leaving rotateKeep pins. Pins which have already been archived, or were marked 📌 before pins were
+// recorded, are only unpinned. Returns the number of pins rotated.
+func (h *Handler) rotateChannel(ctx context.Context, s *discordgo.Session, appID string, config *store.GuildConfig, channels []*discordgo.Channel, c *discordgo.Channel) (int, error) {
+	log := slog.With("guild_id", c.GuildID, "channel_id", c.ID)
+
+	pinned, err := s.ChannelMessagesPinned(c.ID, discordgo.WithContext(ctx))
+	if err != nil {
+		return 0, fmt.Errorf("get pinned messages: %w", err)
+	}
+
+	if len(pinned) < rotateThreshold {
+		return 0, nil
+	}
+
+	// native pins are listed most recently pinned first, so rotate from the end
+	oldest := slices.Clone(pinned[rotateKeep:])
+	slices.Reverse(oldest)
+
+	rotated := 0
+	for _, m := range oldest {
+		m.GuildID = c.GuildID
+		log := log.With("message_id", m.ID)
+
+		_, err := h.store.GetPin(ctx, c.GuildID, m.ID)
+		switch {
+		case errors.Is(err, store.ErrNotFound) && hasReacted(m, emojiPinned):
+			// the message was pinned before pins were recorded, so it's already in the pins channel
+			log.Debug("Message already marked as pinned")
+		case errors.Is(err, store.ErrNotFound):
+			targets, err := getTargetChannels(channels, c, config)
+			if err != nil {
+				return rotated, err
+			}
+
+			outcome, record := h.pin(ctx, s, log, &pinRequest{
+				appID:         appID,
+				config:        config,
+				sourceChannel: c,
+				message:       m,
+				targets:       targets,
+			})
+			if record == nil {
+				// don't unpin anything which couldn't be archived
+				return rotated, fmt.Errorf("archive message %s: %s", m.ID, outcome)
+			}
+		case err != nil:
+			return rotated, err
+		}
+
+		if err := s.ChannelMessageUnpin(c.ID, m.ID, discordgo.WithContext(ctx)); err != nil {
+			return rotated, fmt.Errorf("unpin message %s: %w", m.ID, err)
+		}
+
+		rotated++
+	}
+
+	return rotated, nil
+}
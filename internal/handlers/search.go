@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/commands"
+	"github.com/elliotwms/pinbot/internal/store"
+)
+
+// ComponentSearch routes the page buttons of the search results
+const ComponentSearch = "search"
+
+const (
+	searchPageSize = 10
+
+	// maxSearchResults is the most pins shown across the pages of search results. Pins aren't indexed, so each search
+	// reads all of the guild's pins, and broad searches are narrowed rather than paged through indefinitely.
+	maxSearchResults = 100
+
+	// maxCustomIDLength is the maximum length of a component custom ID
+	maxCustomIDLength = 100
+
+	// maxExcerptLength is the maximum length of a message's content shown in search results
+	maxExcerptLength = 60
+
+	dateFormat        = "2006-01-02"
+	compactDateFormat = "20060102"
+)
+
+func (h *Handler) search(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, o *discordgo.ApplicationCommandInteractionDataOption) error {
+	q := store.Query{
+		Text:       optionValue(o, commands.OptionQuery),
+		AuthorID:   optionValue(o, commands.OptionAuthor),
+		PinnedByID: optionValue(o, commands.OptionPinner),
+		ChannelID:  optionValue(o, commands.OptionChannel),
+		Tag:        optionValue(o, commands.OptionTag),
+	}
+
+	var err error
+	if q.From, q.To, err = parseDateRange(optionValue(o, commands.OptionFrom), optionValue(o, commands.OptionTo)); err != nil {
+		return respondLocalized(ctx, s, i.Interaction, textInvalidDate)
+	}
+
+	return h.respondSearch(ctx, s, i, q, 0)
+}
+
+// SearchComponentHandler updates the search results with the page requested by one of the page buttons. The page and
+// query are encoded in the args by encodeSearch.
+func (h *Handler) SearchComponentHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, _ discordgo.MessageComponentInteractionData, args []string) error {
+	page, q, err := decodeSearch(args)
+	if err != nil {
+		return err
+	}
+
+	return h.respondSearch(ctx, s, i, q, page)
+}
+
+// respondSearch responds with the page of the pins matching the query from the channels the member can view
+func (h *Handler) respondSearch(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, q store.Query, page int) error {
+	log := slog.With("guild_id", i.GuildID)
+
+	viewer, err := newChannelViewer(ctx, s, i.GuildID, i.Member)
+	if err != nil {
+		log.Error("Could not get member permissions", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	embeds, components, err := h.searchPage(ctx, locale(i.Interaction), i.GuildID, q, page, viewer)
+	if err != nil {
+		log.Error("Could not search pins", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds:          &embeds,
+		Components:      &components,
+		AllowedMentions: noMentions(),
+	}, discordgo.WithContext(ctx))
+
+	return err
+}
+
+// searchPage renders a page of the pins matching the query, with buttons to move between pages. Pins from channels
+// the viewer can't view are left out, so that searches don't leak the content of private channels. Only the
+// maxSearchResults most recently posted pins are shown.
+func (h *Handler) searchPage(ctx context.Context, l discordgo.Locale, guildID string, q store.Query, page int, viewer *channelViewer) ([]*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	matches, err := store.Search(ctx, h.store, guildID, q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pins []*store.Pin
+	var truncated bool
+	for _, p := range matches {
+		if !viewer.canView(ctx, p.ChannelID) {
+			continue
+		}
+		if len(pins) == maxSearchResults {
+			truncated = true
+			break
+		}
+		pins = append(pins, p)
+	}
+
+	pages := max(1, (len(pins)+searchPageSize-1)/searchPageSize)
+	page = min(max(page, 0), pages-1)
+
+	embed := &discordgo.MessageEmbed{
+		Title:  localize(l, textSearchFound, localizeCount(l, textPins, len(pins))),
+		Color:  pinMessageColor,
+		Footer: &discordgo.MessageEmbedFooter{Text: localize(l, textSearchPage, page+1, pages)},
+	}
+
+	if len(pins) == 0 {
+		embed.Description = localize(l, textSearchNoMatches)
+	}
+
+	var lines []string
+	for _, p := range pins[page*searchPageSize : min(len(pins), (page+1)*searchPageSize)] {
+		lines = append(lines, searchResult(p))
+	}
+	embed.Description += strings.Join(lines, "\n")
+
+	if truncated {
+		embed.Footer.Text += " • " + localize(l, textSearchNarrow)
+	}
+
+	embeds := []*discordgo.MessageEmbed{embed}
+
+	if pages == 1 {
+		return embeds, []discordgo.MessageComponent{}, nil
+	}
+
+	previous, next := encodeSearch(page-1, q), encodeSearch(page+1, q)
+	if len(previous) > maxCustomIDLength || len(next) > maxCustomIDLength {
+		// the query is too long to be paged through, so only the first page can be shown
+		if !truncated {
+			embed.Footer.Text += " • " + localize(l, textSearchNarrow)
+		}
+		return embeds, []discordgo.MessageComponent{}, nil
+	}
+
+	return embeds, []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    localize(l, textPrevious),
+				Style:    discordgo.SecondaryButton,
+				CustomID: previous,
+				Disabled: page == 0,
+			},
+			discordgo.Button{
+				Label:    localize(l, textNext),
+				Style:    discordgo.SecondaryButton,
+				CustomID: next,
+				Disabled: page == pages-1,
+			},
+		}},
+	}, nil
+}
+
+// searchResult renders a pin as a line of the search results, with a jump link to the original message
+func searchResult(p *store.Pin) string {
+	m := p.Message
+
+	var author string
+	if m.Author != nil {
+		author = m.Author.Username
+	}
+
+	return fmt.Sprintf(
+		"<t:%d:d> <#%s> **%s**: %s [Jump](%s)",
+		m.Timestamp.Unix(),
+		p.ChannelID,
+		author,
+		excerpt(m),
+		url(p.GuildID, p.ChannelID, p.MessageID),
+	)
+}
+
+// excerpt returns the start of the message's content on a single line
+func excerpt(m *discordgo.Message) string {
+	// custom emoji are shortened to their names so they don't use up the excerpt
+	body := replaceCustomEmoji(messageBody(m, nil), func(name, _ string, _ bool) string { return ":" + name + ":" })
+
+	e := strings.Join(strings.Fields(body), " ")
+	if r := []rune(e); len(r) > maxExcerptLength {
+		e = string(r[:maxExcerptLength]) + "…"
+	}
+	if e == "" {
+		e = "*No content*"
+	}
+
+	return e
+}
+
+// parseDateRange parses the from and to dates, where either may be empty. The range includes the whole of the to date.
+func parseDateRange(from, to string) (f, t time.Time, err error) {
+	if from != "" {
+		if f, err = time.Parse(dateFormat, from); err != nil {
+			return
+		}
+	}
+
+	if to != "" {
+		if t, err = time.Parse(dateFormat, to); err != nil {
+			return
+		}
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	return
+}
+
+// encodeSearch encodes the page and query as a custom ID. Custom IDs are limited in length, so IDs are encoded in base
+// 36 and dates in a compact format.
+func encodeSearch(page int, q store.Query) string {
+	var from, to string
+	if !q.From.IsZero() {
+		from = q.From.Format(compactDateFormat)
+	}
+	if !q.To.IsZero() {
+		to = q.To.Format(compactDateFormat)
+	}
+
+	return customID(
+		ComponentSearch,
+		strconv.Itoa(page),
+		neturl.QueryEscape(q.Text),
+		encodeID(q.AuthorID),
+		encodeID(q.PinnedByID),
+		encodeID(q.ChannelID),
+		neturl.QueryEscape(q.Tag),
+		from,
+		to,
+	)
+}
+
+func decodeSearch(args []string) (page int, q store.Query, err error) {
+	if len(args) != 8 {
+		return 0, q, fmt.Errorf("unexpected search component args: %v", args)
+	}
+
+	if page, err = strconv.Atoi(args[0]); err != nil {
+		return
+	}
+	if q.Text, err = neturl.QueryUnescape(args[1]); err != nil {
+		return
+	}
+	if q.AuthorID, err = decodeID(args[2]); err != nil {
+		return
+	}
+	if q.PinnedByID, err = decodeID(args[3]); err != nil {
+		return
+	}
+	if q.ChannelID, err = decodeID(args[4]); err != nil {
+		return
+	}
+	if q.Tag, err = neturl.QueryUnescape(args[5]); err != nil {
+		return
+	}
+	if args[6] != "" {
+		if q.From, err = time.Parse(compactDateFormat, args[6]); err != nil {
+			return
+		}
+	}
+	if args[7] != "" {
+		if q.To, err = time.Parse(compactDateFormat, args[7]); err != nil {
+			return
+		}
+		q.To = q.To.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	return
+}
+
+// plural formats the count of a noun, e.g. "1 pin" or "2 pins"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func encodeID(id string) string {
+	v, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return ""
+	}
+
+	return strconv.FormatUint(v, 36)
+}
+
+func decodeID(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+
+	v, err := strconv.ParseUint(s, 36, 64)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.FormatUint(v, 10), nil
+}
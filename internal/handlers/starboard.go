@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+	"golang.org/x/sync/errgroup"
+)
+
+// JobStarboard routes the scheduled event which pins the messages that have received enough reactions
+const JobStarboard = "starboard"
+
+const (
+	defaultStarboardEmoji     = "⭐"
+	defaultStarboardThreshold = 5
+
+	// starboardWindow is how far back messages are scanned. Messages older than this aren't pinned however many
+	// reactions they receive.
+	starboardWindow = 24 * time.Hour
+)
+
+// StarboardJob scans the recent messages in each opted-in guild's starboard channels, pinning any which have reached
+// the guild's reaction threshold. Bots can't receive reaction events without the gateway, so the messages are
+// scanned on a schedule instead.
+func (h *Handler) StarboardJob(ctx context.Context, s *discordgo.Session, t time.Time) error {
+	configs, err := h.store.ListGuildConfigs(ctx)
+	if err != nil {
+		return fmt.Errorf("list guild configs: %w", err)
+	}
+
+	// the bot's user ID is needed to check its permissions when mirroring
+	bot, err := s.User("@me", discordgo.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("get bot user: %w", err)
+	}
+
+	group := errgroup.Group{}
+	for _, c := range configs {
+		if c.Starboard == nil {
+			continue
+		}
+
+		group.Go(func() error {
+			if err := h.starboard(ctx, s, bot.ID, c, t); err != nil {
+				slog.Error("Could not scan starboard channels", "guild_id", c.GuildID, "error", err)
+			}
+
+			return nil
+		})
+	}
+
+	return group.Wait()
+}
+
+func (h *Handler) starboard(ctx context.Context, s *discordgo.Session, botID string, c *store.GuildConfig, t time.Time) error {
+	emoji := c.Starboard.Emoji
+	if emoji == "" {
+		emoji = defaultStarboardEmoji
+	}
+
+	threshold := c.Starboard.Threshold
+	if threshold <= 0 {
+		threshold = defaultStarboardThreshold
+	}
+
+	channels, err := s.GuildChannels(c.GuildID, discordgo.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	for _, channelID := range c.Starboard.ChannelIDs {
+		log := slog.With("guild_id", c.GuildID, "channel_id", channelID)
+
+		sourceChannel, err := getChannel(channels, channelID)
+		if err != nil {
+			// the channel may have since been deleted
+			log.Warn("Could not find starboard channel")
+			continue
+		}
+
+		messages, err := recentMessages(ctx, s, channelID, t.Add(-starboardWindow))
+		if err != nil {
+			log.Error("Could not get recent messages", "error", err)
+			continue
+		}
+
+		for _, m := range messages {
+			count := reactionCount(m, emoji)
+			if count < threshold || hasReacted(m, emojiPinned) {
+				continue
+			}
+
+			if _, err := h.store.GetPin(ctx, c.GuildID, m.ID); !errors.Is(err, store.ErrNotFound) {
+				// the message has already been pinned, or it can't be determined whether it has
+				continue
+			}
+
+			m.GuildID = c.GuildID
+			log := log.With("message_id", m.ID, "reactions", count)
+
+			targets, err := getTargetChannels(channels, sourceChannel, c)
+			if err != nil {
+				log.Error("Could not determine target channels", "error", err)
+				continue
+			}
+
+			outcome, _ := h.pin(ctx, s, log, &pinRequest{
+				appID:         botID,
+				config:        c,
+				sourceChannel: sourceChannel,
+				message:       m,
+				targets:       targets,
+				reactions:     fmt.Sprintf("%s %d", emojiMarkdown(emoji), count),
+			})
+
+			log.Info("Pinned starboard message", "outcome", outcome.String())
+		}
+	}
+
+	return nil
+}
+
+// recentMessages pages back through the channel's history until it reaches messages posted before since
+func recentMessages(ctx context.Context, s *discordgo.Session, channelID string, since time.Time) ([]*discordgo.Message, error) {
+	var messages []*discordgo.Message
+	var before string
+
+	for {
+		page, err := s.ChannelMessages(channelID, maxMessagesPage, before, "", "", discordgo.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range page {
+			if m.Timestamp.Before(since) {
+				return messages, nil
+			}
+
+			messages = append(messages, m)
+		}
+
+		if len(page) < maxMessagesPage {
+			return messages, nil
+		}
+		before = page[len(page)-1].ID
+	}
+}
+
+// reactionCount returns the number of reactions to the message with the emoji, given as its API name
+func reactionCount(m *discordgo.Message, emoji string) int {
+	for _, r := range m.Reactions {
+		if r.Emoji.APIName() == emoji {
+			return r.Count
+		}
+	}
+
+	return 0
+}
+
+// hasReacted returns true if the bot has reacted to the message with the emoji
+func hasReacted(m *discordgo.Message, emoji string) bool {
+	for _, r := range m.Reactions {
+		if r.Emoji.APIName() == emoji {
+			return r.Me
+		}
+	}
+
+	return false
+}
+
+// emojiMarkdown returns the markdown rendering the emoji, given as its API name
+func emojiMarkdown(emoji string) string {
+	e := &discordgo.Emoji{Name: emoji}
+	if name, id, ok := strings.Cut(emoji, ":"); ok {
+		e = &discordgo.Emoji{Name: name, ID: id}
+	}
+
+	return e.MessageFormat()
+}
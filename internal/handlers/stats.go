@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/commands"
+	"github.com/elliotwms/pinbot/internal/store"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// statsTop is the number of entries shown in each of the leaderboards
+	statsTop = 5
+
+	// statsMonths is the number of months shown in the pins per month breakdown
+	statsMonths = 12
+
+	monthFormat = "2006-01"
+)
+
+// stats is the breakdown of a guild's pins
+type stats struct {
+	total    int
+	authors  []count
+	pinners  []count
+	channels []count
+	months   []count
+}
+
+// count is the number of pins with a key, e.g. an author's ID or a month
+type count struct {
+	key string
+	n   int
+}
+
+// stats responds with the breakdown of the guild's pins. Pins from channels the member can't view aren't counted, so
+// that the breakdown doesn't leak private channels or who posts in them.
+func (h *Handler) stats(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, o *discordgo.ApplicationCommandInteractionDataOption) error {
+	log := slog.With("guild_id", i.GuildID)
+
+	var pins []*store.Pin
+	var viewer *channelViewer
+
+	group := errgroup.Group{}
+	group.Go(func() (err error) {
+		pins, err = h.store.ListPins(ctx, i.GuildID)
+		return
+	})
+	group.Go(func() (err error) {
+		viewer, err = newChannelViewer(ctx, s, i.GuildID, i.Member)
+		return
+	})
+
+	if err := group.Wait(); err != nil {
+		log.Error("Could not list pins", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	pins = slices.DeleteFunc(pins, func(p *store.Pin) bool {
+		return !viewer.canView(ctx, p.ChannelID)
+	})
+
+	st := newStats(pins)
+
+	embeds := []*discordgo.MessageEmbed{statsEmbed(locale(i.Interaction), st)}
+	edit := &discordgo.WebhookEdit{Embeds: &embeds}
+
+	if optionBool(o, commands.OptionCSV) {
+		bs, err := statsCSV(st)
+		if err != nil {
+			return fmt.Errorf("write stats csv: %w", err)
+		}
+
+		edit.Files = []*discordgo.File{{
+			Name:        "pins.csv",
+			ContentType: "text/csv",
+			Reader:      bytes.NewReader(bs),
+		}}
+	}
+
+	_, err := s.InteractionResponseEdit(i.Interaction, edit, discordgo.WithContext(ctx))
+
+	return err
+}
+
+func newStats(pins []*store.Pin) *stats {
+	authors := map[string]int{}
+	pinners := map[string]int{}
+	channels := map[string]int{}
+	months := map[string]int{}
+
+	for _, p := range pins {
+		// backfilled pins of authors with the default avatar don't have the author's ID
+		if p.Message != nil && p.Message.Author != nil && p.Message.Author.ID != "" {
+			authors[p.Message.Author.ID]++
+		}
+		if p.PinnedByID != "" {
+			pinners[p.PinnedByID]++
+		}
+		channels[p.ChannelID]++
+		months[p.PinnedAt.UTC().Format(monthFormat)]++
+	}
+
+	st := &stats{
+		total:    len(pins),
+		authors:  sortCounts(authors),
+		pinners:  sortCounts(pinners),
+		channels: sortCounts(channels),
+		months:   sortCounts(months),
+	}
+
+	// months are shown chronologically rather than by count
+	slices.SortFunc(st.months, func(a, b count) int {
+		return strings.Compare(a.key, b.key)
+	})
+
+	return st
+}
+
+// sortCounts returns the counts from most to fewest pins
+func sortCounts(m map[string]int) []count {
+	counts := make([]count, 0, len(m))
+	for k, n := range m {
+		counts = append(counts, count{key: k, n: n})
+	}
+
+	slices.SortFunc(counts, func(a, b count) int {
+		return cmp.Or(b.n-a.n, strings.Compare(a.key, b.key))
+	})
+
+	return counts
+}
+
+func statsEmbed(l discordgo.Locale, st *stats) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title: "📊 " + localizeCount(l, textPins, st.total),
+		Color: pinMessageColor,
+	}
+
+	if st.total == 0 {
+		embed.Description = localize(l, textStatsEmpty)
+		return embed
+	}
+
+	embed.Fields = []*discordgo.MessageEmbedField{
+		statsField(l, textStatsAuthors, st.authors[:min(len(st.authors), statsTop)], "<@%s>"),
+		statsField(l, textStatsPinners, st.pinners[:min(len(st.pinners), statsTop)], "<@%s>"),
+		statsField(l, textStatsChannels, st.channels[:min(len(st.channels), statsTop)], "<#%s>"),
+		statsField(l, textStatsMonths, st.months[max(0, len(st.months)-statsMonths):], "%s"),
+	}
+
+	return embed
+}
+
+func statsField(l discordgo.Locale, name text, counts []count, format string) *discordgo.MessageEmbedField {
+	lines := make([]string, 0, len(counts))
+	for _, c := range counts {
+		lines = append(lines, fmt.Sprintf(format+": %d", c.key, c.n))
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, localize(l, textStatsNone))
+	}
+
+	return &discordgo.MessageEmbedField{
+		Name:   localize(l, name),
+		Value:  strings.Join(lines, "\n"),
+		Inline: true,
+	}
+}
+
+// statsCSV writes the full breakdown as a CSV, with a row for each entry in each of the breakdowns
+func statsCSV(st *stats) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+
+	_ = w.Write([]string{"breakdown", "id", "pins"})
+
+	for _, b := range []struct {
+		name   string
+		counts []count
+	}{
+		{"author", st.authors},
+		{"pinner", st.pinners},
+		{"channel", st.channels},
+		{"month", st.months},
+	} {
+		for _, c := range b.counts {
+			_ = w.Write([]string{b.name, c.key, strconv.Itoa(c.n)})
+		}
+	}
+
+	w.Flush()
+
+	return buf.Bytes(), w.Error()
+}
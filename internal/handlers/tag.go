@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+	"golang.org/x/sync/errgroup"
+)
+
+// ComponentTag routes the tag select menu sent in response to a successful pin
+const ComponentTag = "tag"
+
+// maxSelectMenuOptions is the maximum number of options Discord allows in a select menu
+const maxSelectMenuOptions = 25
+
+func tagComponents(l discordgo.Locale, tags []string, record *store.Pin) []discordgo.MessageComponent {
+	tags = tags[:min(len(tags), maxSelectMenuOptions)]
+
+	options := make([]discordgo.SelectMenuOption, 0, len(tags))
+	for _, t := range tags {
+		options = append(options, discordgo.SelectMenuOption{
+			Label:   t,
+			Value:   t,
+			Default: slices.Contains(record.Tags, t),
+		})
+	}
+
+	minValues := 0
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{
+				MenuType:    discordgo.StringSelectMenu,
+				CustomID:    customID(ComponentTag, record.MessageID),
+				Placeholder: localize(l, textTags),
+				MinValues:   &minValues,
+				MaxValues:   len(options),
+				Options:     options,
+			},
+		}},
+	}
+}
+
+// TagComponentHandler tags the pin with the tags chosen from the menu sent by respondPinned, replacing any existing
+// tags. The pin is identified by the source message ID in the args.
+func (h *Handler) TagComponentHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.MessageComponentInteractionData, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("unexpected tag component args: %v", args)
+	}
+
+	log := slog.With("guild_id", i.GuildID, "message_id", args[0])
+
+	var record *store.Pin
+	var config *store.GuildConfig
+
+	group := errgroup.Group{}
+	group.Go(func() (err error) {
+		record, err = h.store.GetPin(ctx, i.GuildID, args[0])
+		return
+	})
+	group.Go(func() (err error) {
+		config, err = h.store.GetGuildConfig(ctx, i.GuildID)
+		return
+	})
+
+	if err := group.Wait(); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return respondLocalized(ctx, s, i.Interaction, textPinNotFound)
+		}
+
+		log.Error("Could not get pin", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	// the guild's tags may have changed since the menu was sent
+	tags := slices.DeleteFunc(slices.Clone(data.Values), func(t string) bool {
+		return !slices.Contains(config.Tags, t)
+	})
+
+	record.Tags = tags
+	if err := h.store.PutPin(ctx, record); err != nil {
+		log.Error("Could not record tags", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	// show the tags on each of the pin messages
+	for n, err := range h.editPinMessages(ctx, s, config, record) {
+		if err != nil {
+			t := record.Targets[n]
+			log.Error("Could not edit pin message tags", "pin_channel_id", t.ChannelID, "pin_message_id", t.MessageID, "error", err)
+		}
+	}
+
+	if len(tags) == 0 {
+		return respondLocalized(ctx, s, i.Interaction, textTagsRemoved)
+	}
+
+	return respondLocalized(ctx, s, i.Interaction, textTagged, strings.Join(tags, ", "))
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+	"golang.org/x/sync/errgroup"
+)
+
+// ComponentUnpin routes the Unpin button on pin messages
+const ComponentUnpin = "unpin"
+
+// UnpinComponentHandler retracts the pin whose Unpin button was pressed: its pin messages are deleted, the source
+// message is unmarked and unpinned natively, and the pin record is deleted. Only the member who pinned the message or a
+// member who can manage messages may unpin it. The pin is identified by the source message ID in the args.
+func (h *Handler) UnpinComponentHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, _ discordgo.MessageComponentInteractionData, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("unexpected unpin component args: %v", args)
+	}
+
+	log := slog.With("guild_id", i.GuildID, "message_id", args[0])
+
+	var record *store.Pin
+	var config *store.GuildConfig
+
+	group := errgroup.Group{}
+	group.Go(func() (err error) {
+		record, err = h.store.GetPin(ctx, i.GuildID, args[0])
+		return
+	})
+	group.Go(func() (err error) {
+		config, err = h.store.GetGuildConfig(ctx, i.GuildID)
+		return
+	})
+
+	if err := group.Wait(); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return respondLocalized(ctx, s, i.Interaction, textPinNotFound)
+		}
+
+		log.Error("Could not get pin", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	if i.Member.User.ID != record.PinnedByID && !canManageMessages(i) {
+		return respondLocalized(ctx, s, i.Interaction, textUnpinDenied)
+	}
+
+	if err := h.unpin(ctx, s, log, config, record); err != nil {
+		log.Error("Could not unpin message", "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	log.Info("Unpinned message", "targets", len(record.Targets))
+
+	return respondLocalized(ctx, s, i.Interaction, textUnpinned)
+}
+
+// unpin deletes each of the pin's messages and removes the pin from the source message, before deleting its record.
+// Failing to delete a pin message leaves the record in place so that the unpin can be retried.
+func (h *Handler) unpin(ctx context.Context, s *discordgo.Session, log *slog.Logger, config *store.GuildConfig, record *store.Pin) error {
+	for _, t := range record.Targets {
+		err := s.ChannelMessageDelete(t.ChannelID, t.MessageID, discordgo.WithContext(ctx))
+		if err != nil && restErrorCode(err) != discordgo.ErrCodeUnknownMessage {
+			return fmt.Errorf("delete pin message %s: %w", t.MessageID, err)
+		}
+	}
+
+	// the source message may have since been deleted, so failing to unmark it isn't fatal
+	if err := s.MessageReactionRemove(record.ChannelID, record.MessageID, emojiPinned, "@me", discordgo.WithContext(ctx)); err != nil {
+		log.Warn("Could not remove reaction from message", "error", err)
+	}
+
+	if config.NativePins {
+		if err := s.ChannelMessageUnpin(record.ChannelID, record.MessageID, discordgo.WithContext(ctx)); err != nil {
+			log.Warn("Could not unpin message natively", "error", err)
+		}
+	}
+
+	return h.store.DeletePin(ctx, record.GuildID, record.MessageID)
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/pinbot/internal/store"
+	"golang.org/x/sync/errgroup"
+)
+
+// JobVerify routes the scheduled event which checks for pins whose source message has been deleted
+const JobVerify = "verify"
+
+const (
+	// verifyConcurrency limits the number of source messages fetched at once
+	verifyConcurrency = 5
+
+	// maxUserGuildsPage is the maximum number of guilds Discord returns in a page of the bot's guilds
+	maxUserGuildsPage = 200
+
+	// verifyCycle is the number of days over which the scheduled job verifies every pin, verifying a slice of the older
+	// pins each day so that each run stays short
+	verifyCycle = 30
+
+	// verifyRecent is how long after being pinned a pin is verified every day, as recently pinned messages are the
+	// most likely to be deleted
+	verifyRecent = 7 * 24 * time.Hour
+
+	// snowflakeTimestampShift is the number of bits below the millisecond timestamp in a Discord ID
+	snowflakeTimestampShift = 22
+)
+
+// VerifyJob checks the pins in every guild the bot is in for source messages which have been deleted. Each daily run
+// checks the recent pins, and a rotating slice of the older ones.
+func (h *Handler) VerifyJob(ctx context.Context, s *discordgo.Session, t time.Time) error {
+	var after string
+
+	for {
+		guilds, err := s.UserGuilds(maxUserGuildsPage, "", after, false, discordgo.WithContext(ctx))
+		if err != nil {
+			return fmt.Errorf("list guilds: %w", err)
+		}
+
+		for _, g := range guilds {
+			checked, deleted, err := h.verify(ctx, s, g.ID, func(p *store.Pin) bool {
+				return inVerifyBatch(p, t)
+			})
+			if err != nil {
+				slog.Error("Could not verify pins", "guild_id", g.ID, "error", err)
+				continue
+			}
+
+			slog.Info("Verified pins", "guild_id", g.ID, "checked", checked, "deleted", deleted)
+		}
+
+		if len(guilds) < maxUserGuildsPage {
+			return nil
+		}
+		after = guilds[len(guilds)-1].ID
+	}
+}
+
+func (h *Handler) verifyCommand(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	checked, deleted, err := h.verify(ctx, s, i.GuildID, func(*store.Pin) bool {
+		return true
+	})
+	if err != nil {
+		slog.Error("Could not verify pins", "guild_id", i.GuildID, "error", err)
+		return respondLocalized(ctx, s, i.Interaction, textTemporaryError)
+	}
+
+	return respondLocalized(ctx, s, i.Interaction, textVerified, localizeCount(locale(i.Interaction), textPins, checked), deleted)
+}
+
+// inVerifyBatch returns true if the pin is verified by the scheduled run at t: if it was pinned recently, or if it's in
+// the day's slice of the cycle, which is chosen by the timestamp in its message ID. The ID's low bits are mostly zero, so
+// they would leave some slices empty.
+func inVerifyBatch(p *store.Pin, t time.Time) bool {
+	if t.Sub(p.PinnedAt) < verifyRecent {
+		return true
+	}
+
+	id, err := strconv.ParseUint(p.MessageID, 10, 64)
+	if err != nil {
+		return true
+	}
+
+	day := uint64(t.Unix() / int64(24*time.Hour/time.Second))
+
+	return (id>>snowflakeTimestampShift)%verifyCycle == day%verifyCycle
+}
+
+// verify checks whether the source messages of the guild's pins which match the filter still exist, marking the pins
+// of any which have been deleted. Pins which have already been marked aren't checked again. Returns the number of pins
+// checked, and the number found to be deleted.
+func (h *Handler) verify(ctx context.Context, s *discordgo.Session, guildID string, filter func(*store.Pin) bool) (checked, deleted int, err error) {
+	pins, err := h.store.ListPins(ctx, guildID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	config, err := h.store.GetGuildConfig(ctx, guildID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	pins = slices.DeleteFunc(pins, func(p *store.Pin) bool {
+		return !p.DeletedAt.IsZero() || !filter(p)
+	})
+
+	found := make([]bool, len(pins))
+
+	group := errgroup.Group{}
+	group.SetLimit(verifyConcurrency)
+
+	for n, p := range pins {
+		group.Go(func() error {
+			_, err := s.ChannelMessage(p.ChannelID, p.MessageID, discordgo.WithContext(ctx))
+			if !isDeleted(err) {
+				// other errors may be temporary, so the pin is checked again next time
+				return nil
+			}
+
+			found[n] = true
+			if err := h.markPinDeleted(ctx, s, config, p); err != nil {
+				slog.Error("Could not mark pin deleted", "guild_id", guildID, "message_id", p.MessageID, "error", err)
+			}
+
+			return nil
+		})
+	}
+
+	_ = group.Wait()
+
+	for _, f := range found {
+		if f {
+			deleted++
+		}
+	}
+
+	return len(pins), deleted, nil
+}
+
+// isDeleted returns true if the error shows the message (or its channel) no longer exists
+func isDeleted(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) {
+		return false
+	}
+
+	if restErr.Message != nil {
+		switch restErr.Message.Code {
+		case discordgo.ErrCodeUnknownMessage, discordgo.ErrCodeUnknownChannel:
+			return true
+		}
+	}
+
+	return restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound
+}
+
+// markPinDeleted records that the pin's source message has been deleted, and marks each of its pin messages. The
+// content of the pin messages is kept, as it's now the only copy, along with the copies of the images rehosted when
+// they were posted.
+func (h *Handler) markPinDeleted(ctx context.Context, s *discordgo.Session, config *store.GuildConfig, p *store.Pin) error {
+	p.DeletedAt = time.Now()
+
+	if err := h.store.PutPin(ctx, p); err != nil {
+		return err
+	}
+
+	for n, err := range h.editPinMessages(ctx, s, config, p) {
+		if err != nil {
+			t := p.Targets[n]
+			slog.Warn("Could not edit pin message", "pin_channel_id", t.ChannelID, "pin_message_id", t.MessageID, "error", err)
+		}
+	}
+
+	return nil
+}
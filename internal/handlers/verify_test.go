@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/elliotwms/pinbot/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInVerifyBatch(t *testing.T) {
+	start := time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC)
+
+	t.Run("recent pins are verified every day", func(t *testing.T) {
+		p := &store.Pin{MessageID: "1212121212121212121", PinnedAt: start.Add(-24 * time.Hour)}
+
+		for day := range 6 {
+			require.True(t, inVerifyBatch(p, start.AddDate(0, 0, day)))
+		}
+	})
+
+	t.Run("older pins are verified once a cycle", func(t *testing.T) {
+		for _, id := range []string{"1212121212121212121", "1212121212121212122", "1300000000000000000"} {
+			p := &store.Pin{MessageID: id, PinnedAt: start.AddDate(-1, 0, 0)}
+
+			verified := 0
+			for day := range verifyCycle {
+				if inVerifyBatch(p, start.AddDate(0, 0, day)) {
+					verified++
+				}
+			}
+
+			require.Equal(t, 1, verified, id)
+		}
+	})
+}
+
+func TestInVerifyBatchSpread(t *testing.T) {
+	start := time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC)
+	r := rand.New(rand.NewPCG(1, 2))
+
+	// a year of pins, with IDs whose low bits are zero as they are for most messages
+	const n = 3000
+	const discordEpoch = 1420070400000
+	perDay := make([]int, verifyCycle)
+	for range n {
+		sentAt := start.AddDate(-1, 0, 0).Add(time.Duration(r.Int64N(int64(365 * 24 * time.Hour))))
+		id := uint64(sentAt.UnixMilli()-discordEpoch) << snowflakeTimestampShift
+		p := &store.Pin{MessageID: strconv.FormatUint(id, 10), PinnedAt: start.AddDate(-2, 0, 0)}
+
+		for day := range verifyCycle {
+			if inVerifyBatch(p, start.AddDate(0, 0, day)) {
+				perDay[day]++
+			}
+		}
+	}
+
+	for day, count := range perDay {
+		require.InDelta(t, n/verifyCycle, count, n/verifyCycle/4, "day %d", day)
+	}
+}
@@ -0,0 +1,242 @@
+package pinbot
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/bot-lambda"
+	"github.com/elliotwms/bot-lambda/sessionprovider"
+)
+
+const (
+	headerSignature = "X-Signature-Ed25519"
+	headerTimestamp = "X-Signature-Timestamp"
+)
+
+// ComponentHandler handles a message component interaction. Component custom IDs take the form `name:arg:arg…`, where
+// the name routes the interaction to the handler and the args are passed to it.
+type ComponentHandler func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.MessageComponentInteractionData, args []string) error
+
+// ModalSubmitHandler handles a modal submit interaction. Modal custom IDs are routed in the same way as components.
+type ModalSubmitHandler func(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, data discordgo.ModalSubmitInteractionData, args []string) error
+
+// ImmediateCommandHandler handles an application command which must respond immediately instead of being deferred,
+// such as one which responds with a modal.
+type ImmediateCommandHandler func(ctx context.Context, i *discordgo.InteractionCreate, data discordgo.ApplicationCommandInteractionData) (*discordgo.InteractionResponse, error)
+
+// Endpoint extends the bot_lambda.Endpoint with support for the interaction types its router doesn't handle. Any
+// other interactions are passed through to the underlying endpoint.
+type Endpoint struct {
+	*bot_lambda.Endpoint
+	publicKey  ed25519.PublicKey
+	s          sessionprovider.Provider
+	log        *slog.Logger
+	components map[string]ComponentHandler
+	modals     map[string]ModalSubmitHandler
+	immediate  map[string]ImmediateCommandHandler
+
+	// updates are the component handlers which update the message containing the component, rather than responding
+	// with a new message
+	updates map[string]ComponentHandler
+}
+
+func newEndpoint(e *bot_lambda.Endpoint, k ed25519.PublicKey, s sessionprovider.Provider, l *slog.Logger) *Endpoint {
+	return &Endpoint{
+		Endpoint:   e,
+		publicKey:  k,
+		s:          s,
+		log:        l,
+		components: make(map[string]ComponentHandler),
+		modals:     make(map[string]ModalSubmitHandler),
+		immediate:  make(map[string]ImmediateCommandHandler),
+		updates:    make(map[string]ComponentHandler),
+	}
+}
+
+// WithMessageComponent registers a handler for message components with custom IDs prefixed with name.
+func (e *Endpoint) WithMessageComponent(name string, handler ComponentHandler) *Endpoint {
+	e.components[name] = handler
+
+	return e
+}
+
+// WithUpdateMessageComponent registers a handler for message components with custom IDs prefixed with name, which
+// updates the message containing the component. The update is deferred, after which the handler edits the message with
+// discordgo.Session.InteractionResponseEdit.
+func (e *Endpoint) WithUpdateMessageComponent(name string, handler ComponentHandler) *Endpoint {
+	e.updates[name] = handler
+
+	return e
+}
+
+// WithModalSubmit registers a handler for modals with custom IDs prefixed with name.
+func (e *Endpoint) WithModalSubmit(name string, handler ModalSubmitHandler) *Endpoint {
+	e.modals[name] = handler
+
+	return e
+}
+
+// WithImmediateMessageApplicationCommand registers a discordgo.MessageApplicationCommand which responds immediately,
+// rather than being deferred by the underlying endpoint.
+func (e *Endpoint) WithImmediateMessageApplicationCommand(name string, handler ImmediateCommandHandler) *Endpoint {
+	e.immediate[name] = handler
+
+	return e
+}
+
+// HandleRequest handles the events.LambdaFunctionURLRequest, handling the interactions registered with the Endpoint
+// and passing anything else through to the underlying endpoint.
+func (e *Endpoint) HandleRequest(ctx context.Context, event *events.LambdaFunctionURLRequest) (res *events.LambdaFunctionURLResponse, err error) {
+	var i *discordgo.InteractionCreate
+	if event.RequestContext.HTTP.Method != http.MethodPost || json.Unmarshal([]byte(event.Body), &i) != nil || !e.handles(i) {
+		return e.Endpoint.HandleRequest(ctx, event)
+	}
+
+	ctx, seg := xray.BeginSubsegment(ctx, "handle interaction")
+	_ = seg.AddAnnotation("type", int(i.Type))
+	defer seg.Close(err)
+
+	if err := e.verify(event.Headers, []byte(event.Body)); err != nil {
+		e.log.Error("Failed to verify signature", "error", err)
+		return &events.LambdaFunctionURLResponse{StatusCode: http.StatusUnauthorized}, nil
+	}
+
+	var response *discordgo.InteractionResponse
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		data := i.ApplicationCommandData()
+		response, err = e.immediate[data.Name](ctx, i, data)
+	case discordgo.InteractionMessageComponent:
+		data := i.MessageComponentData()
+		name, args := route(data.CustomID)
+		if h, ok := e.updates[name]; ok {
+			err = e.handleDeferred(ctx, i, name, deferredUpdate, func(s *discordgo.Session) error {
+				return h(ctx, s, i, data, args)
+			})
+			break
+		}
+		err = e.handleDeferred(ctx, i, name, deferredMessage, func(s *discordgo.Session) error {
+			return e.components[name](ctx, s, i, data, args)
+		})
+	case discordgo.InteractionModalSubmit:
+		data := i.ModalSubmitData()
+		name, args := route(data.CustomID)
+		err = e.handleDeferred(ctx, i, name, deferredMessage, func(s *discordgo.Session) error {
+			return e.modals[name](ctx, s, i, data, args)
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if response == nil {
+		return &events.LambdaFunctionURLResponse{StatusCode: http.StatusAccepted}, nil
+	}
+
+	bs, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("marshal interaction response: %w", err)
+	}
+
+	return &events.LambdaFunctionURLResponse{StatusCode: http.StatusOK, Body: string(bs)}, nil
+}
+
+// handles returns true if a handler is registered with the Endpoint for the interaction
+func (e *Endpoint) handles(i *discordgo.InteractionCreate) bool {
+	var ok bool
+
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		_, ok = e.immediate[i.ApplicationCommandData().Name]
+	case discordgo.InteractionMessageComponent:
+		name, _ := route(i.MessageComponentData().CustomID)
+		_, ok = e.components[name]
+		if !ok {
+			_, ok = e.updates[name]
+		}
+	case discordgo.InteractionModalSubmit:
+		name, _ := route(i.ModalSubmitData().CustomID)
+		_, ok = e.modals[name]
+	}
+
+	return ok
+}
+
+var (
+	// deferredMessage defers a response with a new message, which is only shown to the user who sent the interaction
+	deferredMessage = &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	}
+
+	// deferredUpdate defers an update to the message containing the component
+	deferredUpdate = &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	}
+)
+
+// handleDeferred sends the deferred response to the interaction before calling the handler, as the underlying endpoint
+// does with application commands
+func (e *Endpoint) handleDeferred(ctx context.Context, i *discordgo.InteractionCreate, name string, deferred *discordgo.InteractionResponse, h func(s *discordgo.Session) error) error {
+	log := e.log.With(slog.String("interaction", i.ID), slog.String("name", name))
+
+	s, err := e.s(ctx)
+	if err != nil {
+		return fmt.Errorf("get session from source: %w", err)
+	}
+
+	// interaction callbacks are authorised by the interaction token, so the session's client is only used for tracing
+	if err := s.InteractionRespond(i.Interaction, deferred, discordgo.WithContext(ctx)); err != nil {
+		return fmt.Errorf("sending deferred response: %w", err)
+	}
+
+	if err := h(s); err != nil {
+		log.Error("Failed to handle interaction", "error", err)
+	}
+
+	return nil
+}
+
+// route splits a custom ID into the name of its handler and its args
+func route(customID string) (string, []string) {
+	name, args, _ := strings.Cut(customID, ":")
+
+	return name, strings.Split(args, ":")
+}
+
+// verify verifies the request signature in the same way as the underlying endpoint.
+// See https://discord.com/developers/docs/events/webhook-events#setting-up-an-endpoint-validating-security-request-headers.
+func (e *Endpoint) verify(headers map[string]string, body []byte) error {
+	// if no public key is provided then skip verification
+	if len(e.publicKey) == 0 {
+		return nil
+	}
+
+	parsed := make(http.Header, len(headers))
+	for k, v := range headers {
+		parsed.Add(k, v)
+	}
+
+	sig, err := hex.DecodeString(parsed.Get(headerSignature))
+	if err != nil || len(sig) == 0 {
+		return errors.New("invalid signature")
+	}
+
+	if !ed25519.Verify(e.publicKey, append([]byte(parsed.Get(headerTimestamp)), body...), sig) {
+		return errors.New("invalid signature")
+	}
+
+	return nil
+}
@@ -7,10 +7,40 @@ import (
 	"github.com/elliotwms/bot-lambda"
 	"github.com/elliotwms/bot-lambda/sessionprovider"
 	"github.com/elliotwms/bot/interactions/router"
+	"github.com/elliotwms/pinbot/internal/commands"
 	"github.com/elliotwms/pinbot/internal/handlers"
+	"github.com/elliotwms/pinbot/internal/store"
 )
 
-func New(k ed25519.PublicKey, s sessionprovider.Provider, l *slog.Logger) *bot_lambda.Endpoint {
+type options struct {
+	store store.Store
+}
+
+type Option func(*options)
+
+// WithStore overrides the store used to persist pins and guild configuration. Defaults to an in-memory store.
+func WithStore(s store.Store) Option {
+	return func(o *options) {
+		o.store = s
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.store == nil {
+		o.store = store.NewMemory()
+	}
+
+	return o
+}
+
+func New(k ed25519.PublicKey, s sessionprovider.Provider, l *slog.Logger, opts ...Option) *Endpoint {
+	h := handlers.New(newOptions(opts).store)
+
 	e := bot_lambda.
 		New(
 			k,
@@ -19,7 +49,17 @@ func New(k ed25519.PublicKey, s sessionprovider.Provider, l *slog.Logger) *bot_l
 			bot_lambda.WithDeferredResponseEnabled(true),
 		).
 		WithSessionProvider(s).
-		WithMessageApplicationCommand("Pin", handlers.PinMessageCommandHandler)
+		WithMessageApplicationCommand(commands.Pin, h.PinMessageCommandHandler).
+		WithMessageApplicationCommand(commands.PinTo, h.PinToMessageCommandHandler).
+		WithMessageApplicationCommand(commands.RefreshPin, h.RefreshPinMessageCommandHandler).
+		WithChatApplicationCommand(commands.Pins, h.PinsCommandHandler).
+		WithChatApplicationCommand(commands.Pinbot, h.PinbotCommandHandler)
 
-	return e
+	return newEndpoint(e, k, s, l).
+		WithImmediateMessageApplicationCommand(commands.PinWithNote, h.PinWithNoteMessageCommandHandler).
+		WithMessageComponent(handlers.ComponentPinTo, h.PinToComponentHandler).
+		WithMessageComponent(handlers.ComponentTag, h.TagComponentHandler).
+		WithMessageComponent(handlers.ComponentUnpin, h.UnpinComponentHandler).
+		WithUpdateMessageComponent(handlers.ComponentSearch, h.SearchComponentHandler).
+		WithModalSubmit(handlers.ModalPinNote, h.PinWithNoteModalSubmitHandler)
 }
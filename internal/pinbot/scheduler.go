@@ -0,0 +1,93 @@
+package pinbot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/bwmarrin/discordgo"
+	"github.com/elliotwms/bot-lambda/sessionprovider"
+	"github.com/elliotwms/pinbot/internal/handlers"
+)
+
+// Job is run by a scheduled event, at the time of the event
+type Job func(ctx context.Context, s *discordgo.Session, t time.Time) error
+
+// Scheduler handles the EventBridge events which run Pinbot's scheduled jobs. Each job has its own EventBridge rule,
+// and events are routed to the job whose name suffixes the rule's name (e.g. `pinbot-on-this-day`).
+type Scheduler struct {
+	s    sessionprovider.Provider
+	log  *slog.Logger
+	jobs map[string]Job
+}
+
+func NewScheduler(s sessionprovider.Provider, l *slog.Logger, opts ...Option) *Scheduler {
+	h := handlers.New(newOptions(opts).store)
+
+	return (&Scheduler{
+		s:    s,
+		log:  l,
+		jobs: make(map[string]Job),
+	}).
+		WithJob(handlers.JobOnThisDay, h.OnThisDayJob).
+		WithJob(handlers.JobDigest, h.DigestJob).
+		WithJob(handlers.JobVerify, h.VerifyJob).
+		WithJob(handlers.JobStarboard, h.StarboardJob).
+		WithJob(handlers.JobRotate, h.RotateJob)
+}
+
+// WithJob registers a job to be run by the events of rules with names suffixed with name
+func (sc *Scheduler) WithJob(name string, job Job) *Scheduler {
+	sc.jobs[name] = job
+
+	return sc
+}
+
+// HandleEvent runs the job for the rule which triggered the event
+func (sc *Scheduler) HandleEvent(ctx context.Context, event events.EventBridgeEvent) (err error) {
+	name, job, err := sc.route(event)
+	if err != nil {
+		return err
+	}
+
+	ctx, seg := xray.BeginSubsegment(ctx, "run job")
+	_ = seg.AddAnnotation("job", name)
+	defer seg.Close(err)
+
+	s, err := sc.s(ctx)
+	if err != nil {
+		return fmt.Errorf("get session from source: %w", err)
+	}
+
+	log := sc.log.With(slog.String("job", name))
+	log.Info("Running job", "time", event.Time)
+
+	if err := job(ctx, s, event.Time); err != nil {
+		log.Error("Failed to run job", "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// route returns the job for the rule which triggered the event, identified by the rule ARN in the event's resources
+func (sc *Scheduler) route(event events.EventBridgeEvent) (string, Job, error) {
+	for _, r := range event.Resources {
+		_, rule, ok := strings.Cut(r, ":rule/")
+		if !ok {
+			continue
+		}
+
+		for name, job := range sc.jobs {
+			if strings.HasSuffix(rule, name) {
+				return name, job, nil
+			}
+		}
+	}
+
+	return "", nil, fmt.Errorf("no job for event resources: %v", event.Resources)
+}
@@ -0,0 +1,252 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	attributeGuildID = "guild_id"
+	attributeID      = "id"
+	attributeData    = "data"
+
+	idConfig    = "config"
+	idPrefixPin = "pin#"
+
+	// indexID is a global secondary index of the table's keys, partitioned by `id` with a sort key of `guild_id`. It
+	// lists the guilds with a config record without reading their pins.
+	indexID = "id-index"
+
+	// maxBatchGetItems is the maximum number of items DynamoDB returns from a BatchGetItem
+	maxBatchGetItems = 100
+)
+
+// DynamoDBAPI is the subset of the DynamoDB client used by the store
+type DynamoDBAPI interface {
+	GetItem(context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	BatchGetItem(context.Context, *dynamodb.BatchGetItemInput, ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+	dynamodb.QueryAPIClient
+}
+
+// DynamoDB is a Store backed by a single DynamoDB table, partitioned by guild ID (`guild_id`) with a sort key (`id`)
+// describing the record type. Records are stored as JSON in the `data` attribute.
+type DynamoDB struct {
+	client DynamoDBAPI
+	table  string
+}
+
+func NewDynamoDB(client DynamoDBAPI, table string) *DynamoDB {
+	return &DynamoDB{client: client, table: table}
+}
+
+func (d *DynamoDB) GetGuildConfig(ctx context.Context, guildID string) (*GuildConfig, error) {
+	c := &GuildConfig{GuildID: guildID}
+
+	err := d.get(ctx, guildID, idConfig, c)
+	if errors.Is(err, ErrNotFound) {
+		return c, nil
+	}
+
+	return c, err
+}
+
+func (d *DynamoDB) PutGuildConfig(ctx context.Context, c *GuildConfig) error {
+	return d.put(ctx, c.GuildID, idConfig, c)
+}
+
+// ListGuildConfigs queries the ID index for the keys of the config records, then gets the records in batches
+func (d *DynamoDB) ListGuildConfigs(ctx context.Context) ([]*GuildConfig, error) {
+	var keys []map[string]types.AttributeValue
+
+	p := dynamodb.NewQueryPaginator(d.client, &dynamodb.QueryInput{
+		TableName:              aws.String(d.table),
+		IndexName:              aws.String(indexID),
+		KeyConditionExpression: aws.String("#id = :id"),
+		ExpressionAttributeNames: map[string]string{
+			"#id": attributeID,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":id": &types.AttributeValueMemberS{Value: idConfig},
+		},
+	})
+
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("query guild config keys: %w", err)
+		}
+
+		for _, item := range out.Items {
+			keys = append(keys, key(stringValue(item[attributeGuildID]), idConfig))
+		}
+	}
+
+	configs := make([]*GuildConfig, 0, len(keys))
+	for batch := range slices.Chunk(keys, maxBatchGetItems) {
+		items, err := d.batchGet(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			c := &GuildConfig{}
+			if err := unmarshal(item, c); err != nil {
+				return nil, err
+			}
+
+			// config records written by hand don't include the guild ID in their data
+			c.GuildID = stringValue(item[attributeGuildID])
+
+			configs = append(configs, c)
+		}
+	}
+
+	return configs, nil
+}
+
+// batchGet gets the items with the keys, retrying any keys which DynamoDB leaves unprocessed
+func (d *DynamoDB) batchGet(ctx context.Context, keys []map[string]types.AttributeValue) ([]map[string]types.AttributeValue, error) {
+	var items []map[string]types.AttributeValue
+
+	requests := map[string]types.KeysAndAttributes{d.table: {Keys: keys}}
+	for len(requests) > 0 {
+		out, err := d.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: requests})
+		if err != nil {
+			return nil, fmt.Errorf("batch get items: %w", err)
+		}
+
+		items = append(items, out.Responses[d.table]...)
+		requests = out.UnprocessedKeys
+	}
+
+	return items, nil
+}
+
+func (d *DynamoDB) GetPin(ctx context.Context, guildID, messageID string) (*Pin, error) {
+	p := &Pin{}
+
+	if err := d.get(ctx, guildID, idPrefixPin+messageID, p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (d *DynamoDB) PutPin(ctx context.Context, p *Pin) error {
+	return d.put(ctx, p.GuildID, idPrefixPin+p.MessageID, p)
+}
+
+func (d *DynamoDB) DeletePin(ctx context.Context, guildID, messageID string) error {
+	id := idPrefixPin + messageID
+
+	_, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(d.table),
+		Key:       key(guildID, id),
+	})
+	if err != nil {
+		return fmt.Errorf("delete item %s/%s: %w", guildID, id, err)
+	}
+
+	return nil
+}
+
+func (d *DynamoDB) ListPins(ctx context.Context, guildID string) ([]*Pin, error) {
+	var pins []*Pin
+
+	p := dynamodb.NewQueryPaginator(d.client, &dynamodb.QueryInput{
+		TableName:              aws.String(d.table),
+		KeyConditionExpression: aws.String("#guild_id = :guild_id AND begins_with(#id, :prefix)"),
+		ExpressionAttributeNames: map[string]string{
+			"#guild_id": attributeGuildID,
+			"#id":       attributeID,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":guild_id": &types.AttributeValueMemberS{Value: guildID},
+			":prefix":   &types.AttributeValueMemberS{Value: idPrefixPin},
+		},
+	})
+
+	for p.HasMorePages() {
+		out, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("query pins %s: %w", guildID, err)
+		}
+
+		for _, item := range out.Items {
+			pin := &Pin{}
+			if err := unmarshal(item, pin); err != nil {
+				return nil, err
+			}
+
+			pins = append(pins, pin)
+		}
+	}
+
+	return pins, nil
+}
+
+func (d *DynamoDB) get(ctx context.Context, guildID, id string, v any) error {
+	out, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(d.table),
+		Key:       key(guildID, id),
+	})
+	if err != nil {
+		return fmt.Errorf("get item %s/%s: %w", guildID, id, err)
+	}
+
+	return unmarshal(out.Item, v)
+}
+
+func (d *DynamoDB) put(ctx context.Context, guildID, id string, v any) error {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	item := key(guildID, id)
+	item[attributeData] = &types.AttributeValueMemberS{Value: string(bs)}
+
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.table),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("put item %s/%s: %w", guildID, id, err)
+	}
+
+	return nil
+}
+
+// unmarshal unmarshals the item's data into v, returning ErrNotFound if the item is empty
+func unmarshal(item map[string]types.AttributeValue, v any) error {
+	data, ok := item[attributeData].(*types.AttributeValueMemberS)
+	if !ok {
+		return ErrNotFound
+	}
+
+	return json.Unmarshal([]byte(data.Value), v)
+}
+
+func stringValue(v types.AttributeValue) string {
+	if s, ok := v.(*types.AttributeValueMemberS); ok {
+		return s.Value
+	}
+
+	return ""
+}
+
+func key(guildID, id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		attributeGuildID: &types.AttributeValueMemberS{Value: guildID},
+		attributeID:      &types.AttributeValueMemberS{Value: id},
+	}
+}
@@ -0,0 +1,173 @@
+package store
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDynamoDB(t *testing.T) {
+	testStore(t, NewDynamoDB(newFakeDynamoDB(), "pinbot"))
+}
+
+func TestDynamoDBListGuildConfigsWrittenByHand(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeDynamoDB()
+	d := NewDynamoDB(f, "pinbot")
+
+	// as documented, config records only hold the settings in their data
+	for _, guildID := range []string{"1", "2"} {
+		item := key(guildID, idConfig)
+		item[attributeData] = &types.AttributeValueMemberS{Value: `{"rotate": true}`}
+
+		_, err := f.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String("pinbot"), Item: item})
+		require.NoError(t, err)
+	}
+	require.NoError(t, d.PutPin(ctx, testPin("1", "10")))
+
+	configs, err := d.ListGuildConfigs(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []*GuildConfig{
+		{GuildID: "1", Rotate: true},
+		{GuildID: "2", Rotate: true},
+	}, configs)
+}
+
+// fakeDynamoDB is an in-memory table which understands the expressions used by the store. Queries return a single item
+// per page so that pagination is exercised.
+type fakeDynamoDB struct {
+	mu    sync.Mutex
+	items map[[2]string]map[string]types.AttributeValue
+}
+
+func newFakeDynamoDB() *fakeDynamoDB {
+	return &fakeDynamoDB{items: make(map[[2]string]map[string]types.AttributeValue)}
+}
+
+func (f *fakeDynamoDB) GetItem(_ context.Context, in *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return &dynamodb.GetItemOutput{Item: f.items[itemKey(in.Key)]}, nil
+}
+
+func (f *fakeDynamoDB) PutItem(_ context.Context, in *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.items[itemKey(in.Item)] = in.Item
+
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) DeleteItem(_ context.Context, in *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.items, itemKey(in.Key))
+
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (f *fakeDynamoDB) BatchGetItem(_ context.Context, in *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	out := &dynamodb.BatchGetItemOutput{
+		Responses:       map[string][]map[string]types.AttributeValue{},
+		UnprocessedKeys: map[string]types.KeysAndAttributes{},
+	}
+
+	for table, req := range in.RequestItems {
+		keys := req.Keys
+
+		// leave a key unprocessed when there are several, as DynamoDB may, so that retries are exercised
+		if len(keys) > 1 {
+			out.UnprocessedKeys[table] = types.KeysAndAttributes{Keys: keys[len(keys)-1:]}
+			keys = keys[:len(keys)-1]
+		}
+
+		for _, k := range keys {
+			if item, ok := f.items[itemKey(k)]; ok {
+				out.Responses[table] = append(out.Responses[table], item)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+func (f *fakeDynamoDB) Query(_ context.Context, in *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	if aws.ToString(in.IndexName) == indexID {
+		id := stringValue(in.ExpressionAttributeValues[":id"])
+
+		items, next := f.page(in.ExclusiveStartKey, func(k [2]string) bool {
+			return k[1] == id
+		})
+
+		// the index only projects the keys
+		for n, item := range items {
+			items[n] = key(stringValue(item[attributeGuildID]), stringValue(item[attributeID]))
+		}
+
+		return &dynamodb.QueryOutput{Items: items, LastEvaluatedKey: next}, nil
+	}
+
+	guildID := stringValue(in.ExpressionAttributeValues[":guild_id"])
+	prefix := stringValue(in.ExpressionAttributeValues[":prefix"])
+
+	items, next := f.page(in.ExclusiveStartKey, func(k [2]string) bool {
+		return k[0] == guildID && strings.HasPrefix(k[1], prefix)
+	})
+
+	return &dynamodb.QueryOutput{Items: items, LastEvaluatedKey: next}, nil
+}
+
+// page returns the first matching item after the start key, and the key to continue from if there are more
+func (f *fakeDynamoDB) page(start map[string]types.AttributeValue, match func([2]string) bool) ([]map[string]types.AttributeValue, map[string]types.AttributeValue) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var keys [][2]string
+	for k := range f.items {
+		if match(k) && (start == nil || compareKeys(k, itemKey(start)) > 0) {
+			keys = append(keys, k)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	first := keys[0]
+	for _, k := range keys[1:] {
+		if compareKeys(k, first) < 0 {
+			first = k
+		}
+	}
+
+	var next map[string]types.AttributeValue
+	if len(keys) > 1 {
+		next = key(first[0], first[1])
+	}
+
+	return []map[string]types.AttributeValue{f.items[first]}, next
+}
+
+func itemKey(item map[string]types.AttributeValue) [2]string {
+	return [2]string{stringValue(item[attributeGuildID]), stringValue(item[attributeID])}
+}
+
+func compareKeys(a, b [2]string) int {
+	if c := strings.Compare(a[0], b[0]); c != 0 {
+		return c
+	}
+
+	return strings.Compare(a[1], b[1])
+}
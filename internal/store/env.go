@@ -0,0 +1,30 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-xray-sdk-go/instrumentation/awsv2"
+)
+
+// FromEnv returns the DynamoDB store if a table is configured with DYNAMODB_TABLE_NAME, otherwise pins are only held
+// in memory
+func FromEnv(logger *slog.Logger) Store {
+	table := os.Getenv("DYNAMODB_TABLE_NAME")
+	if table == "" {
+		logger.Warn("DYNAMODB_TABLE_NAME not set, pins will not be persisted")
+		return NewMemory()
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		panic(err)
+	}
+
+	awsv2.AWSV2Instrumentor(&cfg.APIOptions)
+
+	return NewDynamoDB(dynamodb.NewFromConfig(cfg), table)
+}
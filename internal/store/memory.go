@@ -0,0 +1,123 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// Memory is an in-memory Store. Records are lost when the process exits, so it is only suitable for testing and local
+// development.
+type Memory struct {
+	mu      sync.RWMutex
+	configs map[string][]byte
+	pins    map[string]map[string][]byte
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		configs: make(map[string][]byte),
+		pins:    make(map[string]map[string][]byte),
+	}
+}
+
+func (m *Memory) GetGuildConfig(_ context.Context, guildID string) (*GuildConfig, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bs, ok := m.configs[guildID]
+	if !ok {
+		return &GuildConfig{GuildID: guildID}, nil
+	}
+
+	c := &GuildConfig{}
+	return c, json.Unmarshal(bs, c)
+}
+
+func (m *Memory) PutGuildConfig(_ context.Context, c *GuildConfig) error {
+	// records are stored serialised so that callers can't mutate them without a put, as with a real store
+	bs, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.configs[c.GuildID] = bs
+
+	return nil
+}
+
+func (m *Memory) ListGuildConfigs(_ context.Context) ([]*GuildConfig, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	configs := make([]*GuildConfig, 0, len(m.configs))
+	for _, bs := range m.configs {
+		c := &GuildConfig{}
+		if err := json.Unmarshal(bs, c); err != nil {
+			return nil, err
+		}
+
+		configs = append(configs, c)
+	}
+
+	return configs, nil
+}
+
+func (m *Memory) GetPin(_ context.Context, guildID, messageID string) (*Pin, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	bs, ok := m.pins[guildID][messageID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	p := &Pin{}
+	return p, json.Unmarshal(bs, p)
+}
+
+func (m *Memory) PutPin(_ context.Context, p *Pin) error {
+	bs, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.pins[p.GuildID]; !ok {
+		m.pins[p.GuildID] = make(map[string][]byte)
+	}
+	m.pins[p.GuildID][p.MessageID] = bs
+
+	return nil
+}
+
+func (m *Memory) DeletePin(_ context.Context, guildID, messageID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.pins[guildID], messageID)
+
+	return nil
+}
+
+func (m *Memory) ListPins(_ context.Context, guildID string) ([]*Pin, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	pins := make([]*Pin, 0, len(m.pins[guildID]))
+	for _, bs := range m.pins[guildID] {
+		p := &Pin{}
+		if err := json.Unmarshal(bs, p); err != nil {
+			return nil, err
+		}
+
+		pins = append(pins, p)
+	}
+
+	return pins, nil
+}
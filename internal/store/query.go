@@ -0,0 +1,69 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Query filters pin records. Each zero value field matches every pin.
+type Query struct {
+	// Text is matched case-insensitively against the pinned message's content and the pin's note
+	Text       string
+	AuthorID   string
+	PinnedByID string
+	ChannelID  string
+	Tag        string
+
+	// From and To bound the time the pinned message was posted
+	From time.Time
+	To   time.Time
+}
+
+// Match returns true if the pin matches all the query's filters
+func (q Query) Match(p *Pin) bool {
+	m := p.Message
+
+	switch {
+	case q.Text != "" && !containsFold(m.Content, q.Text) && !containsFold(p.Note, q.Text):
+		return false
+	case q.AuthorID != "" && (m.Author == nil || m.Author.ID != q.AuthorID):
+		return false
+	case q.PinnedByID != "" && p.PinnedByID != q.PinnedByID:
+		return false
+	case q.ChannelID != "" && p.ChannelID != q.ChannelID:
+		return false
+	case q.Tag != "" && !slices.ContainsFunc(p.Tags, func(t string) bool { return strings.EqualFold(t, q.Tag) }):
+		return false
+	case !q.From.IsZero() && m.Timestamp.Before(q.From):
+		return false
+	case !q.To.IsZero() && m.Timestamp.After(q.To):
+		return false
+	}
+
+	return true
+}
+
+// Search returns the guild's pins which match the query, most recently posted first. Pins aren't indexed by any of
+// the query's fields, so all of the guild's pins are listed and filtered.
+func Search(ctx context.Context, s Store, guildID string, q Query) ([]*Pin, error) {
+	pins, err := s.ListPins(ctx, guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	pins = slices.DeleteFunc(pins, func(p *Pin) bool {
+		return !q.Match(p)
+	})
+
+	slices.SortFunc(pins, func(a, b *Pin) int {
+		return b.Message.Timestamp.Compare(a.Message.Timestamp)
+	})
+
+	return pins, nil
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
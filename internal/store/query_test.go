@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryMatch(t *testing.T) {
+	p := testPin("1", "10")
+	p.Note = "A Classic"
+	p.Tags = []string{"Lore"}
+
+	posted := p.Message.Timestamp
+
+	tests := map[string]struct {
+		q    Query
+		want bool
+	}{
+		"empty":              {q: Query{}, want: true},
+		"content":            {q: Query{Text: "WORLD"}, want: true},
+		"note":               {q: Query{Text: "classic"}, want: true},
+		"text mismatch":      {q: Query{Text: "goodbye"}, want: false},
+		"author":             {q: Query{AuthorID: "200"}, want: true},
+		"author mismatch":    {q: Query{AuthorID: "201"}, want: false},
+		"pinned by":          {q: Query{PinnedByID: "300"}, want: true},
+		"pinned by mismatch": {q: Query{PinnedByID: "301"}, want: false},
+		"channel":            {q: Query{ChannelID: "100"}, want: true},
+		"channel mismatch":   {q: Query{ChannelID: "101"}, want: false},
+		"tag":                {q: Query{Tag: "lore"}, want: true},
+		"tag mismatch":       {q: Query{Tag: "funny"}, want: false},
+		"within range":       {q: Query{From: posted.Add(-time.Hour), To: posted.Add(time.Hour)}, want: true},
+		"before range":       {q: Query{From: posted.Add(time.Hour)}, want: false},
+		"after range":        {q: Query{To: posted.Add(-time.Hour)}, want: false},
+		"all filters":        {q: Query{Text: "hello", AuthorID: "200", ChannelID: "100", Tag: "lore"}, want: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.q.Match(p))
+		})
+	}
+}
+
+func TestQueryMatchWithoutAuthor(t *testing.T) {
+	p := testPin("1", "10")
+	p.Message.Author = nil
+
+	require.False(t, Query{AuthorID: "200"}.Match(p))
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemory()
+
+	for i, content := range []string{"old hello", "goodbye", "new hello"} {
+		p := testPin("1", string(rune('a'+i)))
+		p.Message = &discordgo.Message{
+			Content:   content,
+			Timestamp: time.Date(2024, 1, i+1, 0, 0, 0, 0, time.UTC),
+		}
+		require.NoError(t, s.PutPin(ctx, p))
+	}
+
+	pins, err := Search(ctx, s, "1", Query{Text: "hello"})
+	require.NoError(t, err)
+	require.Len(t, pins, 2)
+	require.Equal(t, "new hello", pins[0].Message.Content)
+	require.Equal(t, "old hello", pins[1].Message.Content)
+}
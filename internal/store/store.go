@@ -0,0 +1,226 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ErrNotFound is returned when a record does not exist in the store
+var ErrNotFound = errors.New("not found")
+
+// Store persists pin records and guild configuration
+type Store interface {
+	// GetGuildConfig returns the configuration for a guild. Guilds without any configuration receive the zero value.
+	GetGuildConfig(ctx context.Context, guildID string) (*GuildConfig, error)
+	PutGuildConfig(ctx context.Context, c *GuildConfig) error
+
+	// ListGuildConfigs returns the configuration of every guild which has any, in no particular order
+	ListGuildConfigs(ctx context.Context) ([]*GuildConfig, error)
+
+	// GetPin returns the pin record for a source message, or ErrNotFound if the message has not been pinned
+	GetPin(ctx context.Context, guildID, messageID string) (*Pin, error)
+	PutPin(ctx context.Context, p *Pin) error
+
+	// DeletePin deletes the pin record for a source message. Deleting a record which doesn't exist is not an error.
+	DeletePin(ctx context.Context, guildID, messageID string) error
+
+	// ListPins returns all the guild's pin records, in no particular order
+	ListPins(ctx context.Context, guildID string) ([]*Pin, error)
+}
+
+// GuildConfig holds the per-guild settings
+type GuildConfig struct {
+	GuildID string `json:"guild_id"`
+
+	// Destinations are the IDs of channels which receive every pin in the guild, in addition to the channel chosen by
+	// name (e.g. a guild-wide #hall-of-fame)
+	Destinations []string `json:"destinations,omitempty"`
+
+	// Mirror optionally mirrors every pin in the guild into a channel in another guild, such as an archive shared by
+	// several guilds. The other guild must accept the mirror by listing this guild in its MirrorSources.
+	Mirror *Mirror `json:"mirror,omitempty"`
+
+	// MirrorSources are the IDs of the guilds permitted to mirror their pins into this guild
+	MirrorSources []string `json:"mirror_sources,omitempty"`
+
+	// Tags are the categories which can be attached to pins in the guild, e.g. "funny", "important", "lore"
+	Tags []string `json:"tags,omitempty"`
+
+	// Template customises the guild's pin messages
+	Template *Template `json:"template,omitempty"`
+
+	// OnThisDay optionally posts the pins from the same date in previous years into a channel each day
+	OnThisDay *OnThisDay `json:"on_this_day,omitempty"`
+
+	// NativePins also pins messages in Discord's own pin list when they are pinned by Pinbot. When the channel is full,
+	// the oldest native pin which Pinbot has archived is unpinned to make room.
+	NativePins bool `json:"native_pins,omitempty"`
+
+	// Rotate archives and unpins the oldest native pins of the guild's channels as they near Discord's pin limit, each
+	// day
+	Rotate bool `json:"rotate,omitempty"`
+
+	// Starboard optionally pins messages automatically once they receive enough reactions
+	Starboard *Starboard `json:"starboard,omitempty"`
+
+	// Refresh enables the "Refresh pin" command, which updates pin messages with edits to their source message
+	Refresh bool `json:"refresh,omitempty"`
+
+	// Digest optionally posts a weekly recap of the guild's most popular pins into a channel
+	Digest *Digest `json:"digest,omitempty"`
+
+	// ResponseMode is how the member pinning a message is told it has been pinned, either ResponseModeEphemeral,
+	// ResponseModePublic or ResponseModeSilent. Errors are always only shown to the member. Defaults to
+	// ResponseModeEphemeral.
+	ResponseMode string `json:"response_mode,omitempty"`
+}
+
+// Response modes
+const (
+	// ResponseModeEphemeral shows the confirmation only to the member who pinned the message
+	ResponseModeEphemeral = "ephemeral"
+
+	// ResponseModePublic shows the confirmation to everyone in the channel
+	ResponseModePublic = "public"
+
+	// ResponseModeSilent doesn't confirm the pin, leaving the 📌 reaction on the message as the only confirmation
+	ResponseModeSilent = "silent"
+)
+
+// Starboard configures a guild's automatic pinning of popular messages
+type Starboard struct {
+	// ChannelIDs are the channels whose messages are pinned automatically
+	ChannelIDs []string `json:"channel_ids"`
+
+	// Emoji is the reaction which is counted, either a unicode emoji or a custom emoji as `name:id`. Defaults to ⭐.
+	Emoji string `json:"emoji,omitempty"`
+
+	// Threshold is the number of reactions a message needs to be pinned. Defaults to 5.
+	Threshold int `json:"threshold,omitempty"`
+}
+
+// Digest configures a guild's weekly digest
+type Digest struct {
+	ChannelID string `json:"channel_id"`
+
+	// TopN is the number of pins included in the digest, ranked by the reactions on their pin messages. Defaults to 10.
+	TopN int `json:"top_n,omitempty"`
+}
+
+// Template customises the appearance of pin messages. Zero values keep the default appearance.
+type Template struct {
+	// Layout is how pin messages are built, either LayoutEmbed or LayoutComponents. Defaults to LayoutEmbed.
+	Layout string `json:"layout,omitempty"`
+
+	// Color of the pin message's embeds
+	Color int `json:"color,omitempty"`
+
+	Title string `json:"title,omitempty"`
+
+	// Fields are the fields shown on the pin message, in order, from TemplateFieldChannel, TemplateFieldPinnedBy and
+	// TemplateFieldNote. Leaving the pinner's field out hides who pinned the message. Defaults to all of them.
+	Fields []string `json:"fields,omitempty"`
+
+	Footer string `json:"footer,omitempty"`
+
+	// Timestamp is the time shown on the pin message, either TimestampPosted, TimestampPinned or TimestampNone.
+	// Defaults to TimestampPosted.
+	Timestamp string `json:"timestamp,omitempty"`
+}
+
+// Layouts of pin messages
+const (
+	// LayoutEmbed builds pin messages from rich embeds, with an embed per image
+	LayoutEmbed = "embed"
+
+	// LayoutComponents builds pin messages from components, with the images in a gallery
+	LayoutComponents = "components"
+)
+
+// Fields which can be shown on pin messages
+const (
+	TemplateFieldChannel  = "channel"
+	TemplateFieldPinnedBy = "pinned_by"
+	TemplateFieldNote     = "note"
+)
+
+// Timestamps which can be shown on pin messages
+const (
+	TimestampPosted = "posted"
+	TimestampPinned = "pinned"
+	TimestampNone   = "none"
+)
+
+// OnThisDay is the channel which receives a guild's "on this day" pins
+type OnThisDay struct {
+	ChannelID string `json:"channel_id"`
+}
+
+// Mirror is a channel in another guild which receives a guild's pins
+type Mirror struct {
+	GuildID   string `json:"guild_id"`
+	ChannelID string `json:"channel_id"`
+}
+
+// Pin is the record of a message which has been pinned by Pinbot
+type Pin struct {
+	GuildID   string `json:"guild_id"`
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+
+	// Message is a snapshot of the source message at the time it was pinned, or last refreshed
+	Message *discordgo.Message `json:"message"`
+
+	PinnedByID string `json:"pinned_by_id,omitempty"`
+
+	// PinnedByName is the name the member who pinned the message was shown by in the guild when they pinned it
+	PinnedByName string    `json:"pinned_by_name,omitempty"`
+	PinnedAt     time.Time `json:"pinned_at"`
+
+	// SyncedAt is when the pin messages were last refreshed from the source message
+	SyncedAt time.Time `json:"synced_at,omitzero"`
+
+	// DeletedAt is when the source message was found to have been deleted. The snapshot is all that remains of it.
+	DeletedAt time.Time `json:"deleted_at,omitzero"`
+
+	// Note is an optional annotation added by the user who pinned the message
+	Note string `json:"note,omitempty"`
+
+	// Tags are the guild's tags which have been attached to the pin
+	Tags []string `json:"tags,omitempty"`
+
+	// Reactions are the reactions which pinned the message to the guild's starboard, e.g. "⭐ 5"
+	Reactions string `json:"reactions,omitempty"`
+
+	// Targets are the pin messages posted for the source message
+	Targets []Target `json:"targets"`
+}
+
+// Target is a pin message posted by Pinbot
+type Target struct {
+	GuildID   string `json:"guild_id"`
+	ChannelID string `json:"channel_id"`
+	MessageID string `json:"message_id"`
+
+	// Rehosted is true if the pin message holds copies of the source message's images, which outlive the source
+	// message
+	Rehosted bool `json:"rehosted,omitempty"`
+
+	// Layout is the layout the pin message was posted in, which it keeps when edited as Discord doesn't allow
+	// messages to switch between layouts. Pin messages recorded without a layout are LayoutEmbed.
+	Layout string `json:"layout,omitempty"`
+}
+
+// HasTarget returns true if the pin has already been posted in the channel
+func (p *Pin) HasTarget(channelID string) bool {
+	for _, t := range p.Targets {
+		if t.ChannelID == channelID {
+			return true
+		}
+	}
+
+	return false
+}
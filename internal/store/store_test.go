@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/require"
+)
+
+// testStore runs the behaviour every Store must share against s
+func testStore(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	t.Run("missing guild config is the zero value", func(t *testing.T) {
+		c, err := s.GetGuildConfig(ctx, "missing")
+		require.NoError(t, err)
+		require.Equal(t, &GuildConfig{GuildID: "missing"}, c)
+	})
+
+	t.Run("guild config round trips", func(t *testing.T) {
+		want := &GuildConfig{GuildID: "1", Destinations: []string{"2"}, Tags: []string{"lore"}, Rotate: true}
+		require.NoError(t, s.PutGuildConfig(ctx, want))
+
+		got, err := s.GetGuildConfig(ctx, "1")
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+
+		configs, err := s.ListGuildConfigs(ctx)
+		require.NoError(t, err)
+		require.Contains(t, configs, want)
+	})
+
+	t.Run("missing pin is not found", func(t *testing.T) {
+		_, err := s.GetPin(ctx, "1", "missing")
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("pins round trip", func(t *testing.T) {
+		want := testPin("1", "10")
+		require.NoError(t, s.PutPin(ctx, want))
+		require.NoError(t, s.PutPin(ctx, testPin("1", "11")))
+		require.NoError(t, s.PutPin(ctx, testPin("2", "12")))
+
+		got, err := s.GetPin(ctx, "1", "10")
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+
+		pins, err := s.ListPins(ctx, "1")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"10", "11"}, messageIDs(pins))
+	})
+
+	t.Run("records can't be mutated without a put", func(t *testing.T) {
+		p, err := s.GetPin(ctx, "1", "10")
+		require.NoError(t, err)
+		p.Note = "changed"
+
+		p, err = s.GetPin(ctx, "1", "10")
+		require.NoError(t, err)
+		require.Empty(t, p.Note)
+	})
+
+	t.Run("pins can be deleted", func(t *testing.T) {
+		require.NoError(t, s.DeletePin(ctx, "1", "11"))
+		require.NoError(t, s.DeletePin(ctx, "1", "missing"))
+
+		_, err := s.GetPin(ctx, "1", "11")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		pins, err := s.ListPins(ctx, "1")
+		require.NoError(t, err)
+		require.Equal(t, []string{"10"}, messageIDs(pins))
+	})
+}
+
+func testPin(guildID, messageID string) *Pin {
+	return &Pin{
+		GuildID:   guildID,
+		ChannelID: "100",
+		MessageID: messageID,
+		Message: &discordgo.Message{
+			ID:        messageID,
+			ChannelID: "100",
+			Content:   "hello world",
+			Author:    &discordgo.User{ID: "200", Username: "author"},
+			Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			// discordgo always unmarshals components as a slice
+			Components: []discordgo.MessageComponent{},
+		},
+		PinnedByID: "300",
+		PinnedAt:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Targets:    []Target{{GuildID: guildID, ChannelID: "400", MessageID: "500"}},
+	}
+}
+
+func messageIDs(pins []*Pin) []string {
+	ids := make([]string, 0, len(pins))
+	for _, p := range pins {
+		ids = append(ids, p.MessageID)
+	}
+
+	slices.Sort(ids)
+
+	return ids
+}
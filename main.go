@@ -9,6 +9,7 @@ import (
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/elliotwms/bot-lambda/sessionprovider"
 	"github.com/elliotwms/pinbot/internal/pinbot"
+	"github.com/elliotwms/pinbot/internal/store"
 )
 
 func init() {
@@ -32,7 +33,7 @@ func main() {
 	src := sessionprovider.Cached(sessionprovider.ParamStore(
 		os.Getenv("PARAM_DISCORD_TOKEN"),
 	))
-	h := pinbot.New(k, src, logger)
+	h := pinbot.New(k, src, logger, pinbot.WithStore(store.FromEnv(logger)))
 
 	lambda.StartWithOptions(h.HandleRequest)
 }
@@ -3,9 +3,11 @@ package tests
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -16,6 +18,7 @@ import (
 	"github.com/bwmarrin/snowflake"
 	"github.com/elliotwms/bot-lambda/sessionprovider"
 	"github.com/elliotwms/pinbot/internal/pinbot"
+	"github.com/elliotwms/pinbot/internal/store"
 	"github.com/neilotoole/slogt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -28,16 +31,26 @@ type PinStage struct {
 	assert  *assert.Assertions
 
 	handler func(_ context.Context, event *events.LambdaFunctionURLRequest) (*events.LambdaFunctionURLResponse, error)
+	job     func(_ context.Context, event events.EventBridgeEvent) error
+	bot     *transport
 	res     *events.LambdaFunctionURLResponse
 	err     error
+	store   *store.Memory
 
 	sendMessage         *discordgo.MessageSend
 	channel             *discordgo.Channel
+	channels            map[string]*discordgo.Channel
 	expectedPinsChannel *discordgo.Channel
 
-	message     *discordgo.Message
-	messages    []*discordgo.Message
-	pinMessage  *discordgo.Message
+	permissions int64
+
+	mirrorGuild *discordgo.Guild
+
+	message    *discordgo.Message
+	messages   []*discordgo.Message
+	pinMessage *discordgo.Message
+	// nativePins are the messages pinned natively before the test, oldest first
+	nativePins  []*discordgo.Message
 	snowflake   *snowflake.Node
 	interaction *discordgo.Interaction
 }
@@ -46,7 +59,17 @@ func NewPinStage(t *testing.T) (*PinStage, *PinStage, *PinStage) {
 	slog.SetDefault(slogt.New(t))
 
 	node, _ := snowflake.NewNode(0)
-	e := pinbot.New(nil, sessionprovider.Static(session), slog.Default())
+	st := store.NewMemory()
+
+	// the bot has its own session without a gateway connection, as in Lambda, whose requests can be intercepted
+	bot, err := discordgo.New("Bot " + testToken)
+	require.NoError(t, err)
+	tr := newTransport()
+	tr.addMember(&discordgo.Member{GuildID: testGuildID, User: &discordgo.User{ID: testAppID, Username: "Pinbot", Bot: true}})
+	bot.Client = &http.Client{Transport: tr, Timeout: 20 * time.Second}
+
+	e := pinbot.New(nil, sessionprovider.Static(bot), slog.Default(), pinbot.WithStore(st))
+	sc := pinbot.NewScheduler(sessionprovider.Static(bot), slog.Default(), pinbot.WithStore(st))
 
 	s := &PinStage{
 		t:         t,
@@ -54,6 +77,10 @@ func NewPinStage(t *testing.T) (*PinStage, *PinStage, *PinStage) {
 		require:   require.New(t),
 		assert:    assert.New(t),
 		handler:   e.HandleRequest,
+		job:       sc.HandleEvent,
+		bot:       tr,
+		store:     st,
+		channels:  map[string]*discordgo.Channel{},
 		snowflake: node,
 	}
 
@@ -81,6 +108,7 @@ func (s *PinStage) a_channel_named(name string) *PinStage {
 		// register the first created channel as the "default" channel for the stage
 		s.channel = c
 	}
+	s.channels[name] = c
 	// register the last created channel as the expected pins channel
 	s.expectedPinsChannel = c
 
@@ -89,6 +117,84 @@ func (s *PinStage) a_channel_named(name string) *PinStage {
 	return s
 }
 
+// a_private_channel_named creates a channel which @everyone, including the bot, can't view
+func (s *PinStage) a_private_channel_named(name string) *PinStage {
+	c, err := s.session.GuildChannelCreateComplex(testGuildID, discordgo.GuildChannelCreateData{
+		Name: name,
+		Type: discordgo.ChannelTypeGuildText,
+		PermissionOverwrites: []*discordgo.PermissionOverwrite{{
+			ID:   testGuildID,
+			Type: discordgo.PermissionOverwriteTypeRole,
+			Deny: discordgo.PermissionViewChannel,
+		}},
+	})
+	s.require.NoError(err)
+
+	s.t.Cleanup(func() {
+		_, err = s.session.ChannelDelete(c.ID)
+		s.assert.NoError(err)
+	})
+
+	s.channels[name] = c
+
+	s.session.AddHandler(s.handleMessageFor(c.ID))
+
+	return s
+}
+
+// a_mirror_channel_named creates a channel in another guild, which the guild mirrors its pins into
+func (s *PinStage) a_mirror_channel_named(name string) *PinStage {
+	return s.mirrorChannel(name)
+}
+
+// a_mirror_channel_the_bot_cannot_post_in_named creates a mirror channel which the bot can view but not post in
+func (s *PinStage) a_mirror_channel_the_bot_cannot_post_in_named(name string) *PinStage {
+	return s.mirrorChannel(name, &discordgo.PermissionOverwrite{
+		ID:   testAppID,
+		Type: discordgo.PermissionOverwriteTypeMember,
+		Deny: discordgo.PermissionSendMessages,
+	})
+}
+
+func (s *PinStage) mirrorChannel(name string, overwrites ...*discordgo.PermissionOverwrite) *PinStage {
+	g, err := s.session.GuildCreate(testGuildName + " Archive")
+	s.require.NoError(err)
+	s.mirrorGuild = g
+
+	s.t.Cleanup(func() {
+		s.assert.NoError(s.session.GuildDelete(g.ID))
+	})
+
+	c, err := s.session.GuildChannelCreateComplex(g.ID, discordgo.GuildChannelCreateData{
+		Name:                 name,
+		Type:                 discordgo.ChannelTypeGuildText,
+		PermissionOverwrites: overwrites,
+	})
+	s.require.NoError(err)
+	s.channels[name] = c
+
+	s.session.AddHandler(s.handleMessageFor(c.ID))
+
+	config, err := s.store.GetGuildConfig(context.Background(), testGuildID)
+	s.require.NoError(err)
+
+	config.Mirror = &store.Mirror{GuildID: g.ID, ChannelID: c.ID}
+	s.require.NoError(s.store.PutGuildConfig(context.Background(), config))
+
+	return s
+}
+
+// the_mirror_guild_accepts_pins_from_the_guild lists the guild in the mirror guild's sources
+func (s *PinStage) the_mirror_guild_accepts_pins_from_the_guild() *PinStage {
+	c, err := s.store.GetGuildConfig(context.Background(), s.mirrorGuild.ID)
+	s.require.NoError(err)
+
+	c.MirrorSources = append(c.MirrorSources, testGuildID)
+	s.require.NoError(s.store.PutGuildConfig(context.Background(), c))
+
+	return s
+}
+
 func (s *PinStage) a_message() *PinStage {
 	s.sendMessage = &discordgo.MessageSend{
 		Content: "Hello, World!",
@@ -109,15 +215,103 @@ func (s *PinStage) the_message_is_posted() *PinStage {
 	return s
 }
 
+// the_message_is_posted_in posts the message in the channel rather than the first channel created
+func (s *PinStage) the_message_is_posted_in(name string) *PinStage {
+	s.channel = s.channels[name]
+
+	return s.the_message_is_posted()
+}
+
 func (s *PinStage) the_pin_command_is_sent_for_the_message() *PinStage {
-	i := &discordgo.InteractionCreate{
+	return s.sendInteraction(s.messageCommand("Pin"))
+}
+
+func (s *PinStage) the_pin_with_note_command_is_sent_for_the_message() *PinStage {
+	return s.handleInteraction(s.messageCommand("Pin with note"))
+}
+
+func (s *PinStage) the_refresh_pin_command_is_sent_for_the_message() *PinStage {
+	return s.sendInteraction(s.messageCommand("Refresh pin"))
+}
+
+// the_refresh_pin_command_is_sent_for_the_pin_message sends the command for the first of the pin's messages
+func (s *PinStage) the_refresh_pin_command_is_sent_for_the_pin_message() *PinStage {
+	p, err := s.store.GetPin(context.Background(), testGuildID, s.message.ID)
+	s.require.NoError(err)
+	s.require.NotEmpty(p.Targets)
+
+	m, err := s.session.ChannelMessage(p.Targets[0].ChannelID, p.Targets[0].MessageID)
+	s.require.NoError(err)
+
+	i := s.messageCommand("Refresh pin")
+	i.ChannelID = m.ChannelID
+	i.Data = discordgo.ApplicationCommandInteractionData{
+		ID:          s.snowflake.Generate().String(),
+		Name:        "Refresh pin",
+		CommandType: discordgo.MessageApplicationCommand,
+		TargetID:    m.ID,
+		Resolved: &discordgo.ApplicationCommandInteractionDataResolved{
+			Messages: map[string]*discordgo.Message{m.ID: m},
+		},
+	}
+
+	return s.sendInteraction(i)
+}
+
+func (s *PinStage) the_pins_command_is_sent(subcommand string, options ...*discordgo.ApplicationCommandInteractionDataOption) *PinStage {
+	return s.sendInteraction(s.chatCommand("pins", subcommand, options...))
+}
+
+func (s *PinStage) the_pinbot_command_is_sent(subcommand string, options ...*discordgo.ApplicationCommandInteractionDataOption) *PinStage {
+	return s.sendInteraction(s.chatCommand("pinbot", subcommand, options...))
+}
+
+func (s *PinStage) chatCommand(name, subcommand string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    s.snowflake.Generate().String(),
+			AppID: testAppID,
+			Type:  discordgo.InteractionApplicationCommand,
+			Data: discordgo.ApplicationCommandInteractionData{
+				ID:          s.snowflake.Generate().String(),
+				Name:        name,
+				CommandType: discordgo.ChatApplicationCommand,
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					{
+						Name:    subcommand,
+						Type:    discordgo.ApplicationCommandOptionSubCommand,
+						Options: options,
+					},
+				},
+			},
+			GuildID:   testGuildID,
+			ChannelID: s.channel.ID,
+			Member: &discordgo.Member{
+				User: &discordgo.User{
+					ID: s.snowflake.Generate().String(),
+				},
+				Permissions: s.permissions,
+			},
+			Version: 1,
+		},
+	}
+}
+
+func (s *PinStage) the_user_can_manage_the_server() *PinStage {
+	s.permissions |= discordgo.PermissionManageGuild
+
+	return s
+}
+
+func (s *PinStage) messageCommand(name string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{
 		Interaction: &discordgo.Interaction{
 			ID:    s.snowflake.Generate().String(),
 			AppID: testAppID,
 			Type:  discordgo.InteractionApplicationCommand,
 			Data: discordgo.ApplicationCommandInteractionData{
 				ID:          s.snowflake.Generate().String(), // todo command ID
-				Name:        "Pin",
+				Name:        name,
 				CommandType: discordgo.MessageApplicationCommand,
 				TargetID:    s.message.ID,
 				Resolved: &discordgo.ApplicationCommandInteractionDataResolved{
@@ -137,18 +331,81 @@ func (s *PinStage) the_pin_command_is_sent_for_the_message() *PinStage {
 			Version: 1,
 		},
 	}
+}
+
+func (s *PinStage) a_channel_is_chosen_from_the_pin_to_menu(name string) *PinStage {
+	i := &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    s.snowflake.Generate().String(),
+			AppID: testAppID,
+			Type:  discordgo.InteractionMessageComponent,
+			Data: discordgo.MessageComponentInteractionData{
+				CustomID:      "pin_to:" + s.message.ChannelID + ":" + s.message.ID,
+				ComponentType: discordgo.ChannelSelectMenuComponent,
+				Values:        []string{s.channels[name].ID},
+			},
+			GuildID:   testGuildID,
+			ChannelID: s.message.ChannelID,
+			Member: &discordgo.Member{
+				User: &discordgo.User{
+					ID: s.snowflake.Generate().String(),
+				},
+			},
+			Version: 1,
+		},
+	}
+
+	return s.sendInteraction(i)
+}
+
+func (s *PinStage) a_note_is_submitted_for_the_message(note string) *PinStage {
+	i := &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    s.snowflake.Generate().String(),
+			AppID: testAppID,
+			Type:  discordgo.InteractionModalSubmit,
+			Data: discordgo.ModalSubmitInteractionData{
+				CustomID: "pin_note:" + s.message.ChannelID + ":" + s.message.ID,
+				Components: []discordgo.MessageComponent{
+					discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+						discordgo.TextInput{CustomID: "note", Value: note},
+					}},
+				},
+			},
+			GuildID:   testGuildID,
+			ChannelID: s.message.ChannelID,
+			Member: &discordgo.Member{
+				User: &discordgo.User{
+					ID: s.snowflake.Generate().String(),
+				},
+			},
+			Version: 1,
+		},
+	}
 
 	return s.sendInteraction(i)
 }
 
+// sendInteraction sends the interaction to the handler, expecting it to be deferred
 func (s *PinStage) sendInteraction(i *discordgo.InteractionCreate) *PinStage {
+	s.handleInteraction(i)
+
+	s.require.Equal(http.StatusAccepted, s.res.StatusCode)
+	s.require.Empty(s.res.Body)
+
+	return s
+}
+
+func (s *PinStage) handleInteraction(i *discordgo.InteractionCreate) *PinStage {
 	// create the interaction in fakediscord
+	data := i.Data
 	i, err := fakediscord.Interaction(i)
 	s.require.NoError(err)
+	i.Data = data
 
 	s.interaction = i.Interaction
 
-	bs, err := json.Marshal(i)
+	bs, err := marshalInteraction(i)
 	s.require.NoError(err)
 
 	ctx, _ := xray.BeginSegment(context.Background(), "test")
@@ -164,8 +421,64 @@ func (s *PinStage) sendInteraction(i *discordgo.InteractionCreate) *PinStage {
 	})
 
 	s.require.NoError(s.err)
-	s.require.Equal(http.StatusAccepted, s.res.StatusCode)
-	s.require.Empty(s.res.Body)
+
+	return s
+}
+
+// marshalInteraction marshals the interaction as Discord would send it. discordgo doesn't marshal the components of
+// submitted modals, so they are added to the data.
+func marshalInteraction(i *discordgo.InteractionCreate) ([]byte, error) {
+	bs, err := json.Marshal(i)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := i.Data.(discordgo.ModalSubmitInteractionData)
+	if !ok {
+		return bs, nil
+	}
+
+	v := map[string]any{}
+	if err := json.Unmarshal(bs, &v); err != nil {
+		return nil, err
+	}
+
+	v["data"] = map[string]any{
+		"custom_id":  data.CustomID,
+		"components": data.Components,
+	}
+
+	return json.Marshal(v)
+}
+
+func (s *PinStage) the_bot_should_respond_with_a_modal() *PinStage {
+	s.require.Equal(http.StatusOK, s.res.StatusCode)
+
+	// discordgo can't unmarshal the modal's components, so only the fields which are checked are decoded
+	res := &struct {
+		Type discordgo.InteractionResponseType `json:"type"`
+		Data struct {
+			CustomID string `json:"custom_id"`
+		} `json:"data"`
+	}{}
+	s.require.NoError(json.Unmarshal([]byte(s.res.Body), res))
+	s.require.Equal(discordgo.InteractionResponseModal, res.Type)
+	s.require.Equal("pin_note:"+s.message.ChannelID+":"+s.message.ID, res.Data.CustomID)
+
+	return s
+}
+
+func (s *PinStage) the_pin_message_should_have_a_field(name, value string) *PinStage {
+	s.require.NotEmpty(s.pinMessage.Embeds)
+
+	for _, f := range s.pinMessage.Embeds[0].Fields {
+		if f.Name == name {
+			s.require.Equal(value, f.Value)
+			return s
+		}
+	}
+
+	s.require.Failf("field not found", "no %q field in pin message", name)
 
 	return s
 }
@@ -191,87 +504,904 @@ func (s *PinStage) a_pin_message_should_be_posted_in_the_last_channel() *PinStag
 	return s
 }
 
-func (s *PinStage) the_bot_should_add_the_emoji(emoji string) *PinStage {
-	s.require.Eventually(func() bool {
-		reactions, err := s.session.MessageReactions(s.channel.ID, s.message.ID, emoji, 0, "", "")
-		if err != nil {
-			return false
-		}
+func (s *PinStage) a_destination_channel_named(name string) *PinStage {
+	// destinations don't affect the expected pins channel, which is chosen by name
+	expected := s.expectedPinsChannel
+	s.a_channel_named(name)
+	s.expectedPinsChannel = expected
 
-		for _, r := range reactions {
-			if r.ID == s.session.State.User.ID {
-				return true
-			}
-		}
+	c, err := s.store.GetGuildConfig(context.Background(), testGuildID)
+	s.require.NoError(err)
 
-		return false
-	}, 5*time.Second, 500*time.Millisecond)
+	c.Destinations = append(c.Destinations, s.channels[name].ID)
+	s.require.NoError(s.store.PutGuildConfig(context.Background(), c))
 
 	return s
 }
 
-func (s *PinStage) handleMessageFor(channelID string) func(*discordgo.Session, *discordgo.MessageCreate) {
-	return func(_ *discordgo.Session, m *discordgo.MessageCreate) {
-		if m.ChannelID == channelID {
-			s.messages = append(s.messages, m.Message)
-		}
+func (s *PinStage) the_bot_cannot_post_in(name string) *PinStage {
+	s.bot.deny(s.channels[name].ID)
+
+	return s
+}
+
+// n_pins_were_recorded_in records pins of messages in the channel, as if they had been pinned
+func (s *PinStage) n_pins_were_recorded_in(n int, name string) *PinStage {
+	c := s.channels[name]
+
+	for range n {
+		id := s.snowflake.Generate().String()
+
+		s.require.NoError(s.store.PutPin(context.Background(), &store.Pin{
+			GuildID:   testGuildID,
+			ChannelID: c.ID,
+			MessageID: id,
+			Message: &discordgo.Message{
+				ID:        id,
+				ChannelID: c.ID,
+				GuildID:   testGuildID,
+				Content:   "Hello, World!",
+				Author:    &discordgo.User{ID: s.snowflake.Generate().String(), Username: "author"},
+				Timestamp: time.Now(),
+			},
+			PinnedAt: time.Now(),
+		}))
 	}
+
+	return s
 }
 
-func (s *PinStage) the_message_is_already_marked_as_pinned() {
-	s.require.NoError(s.session.MessageReactionAdd(s.message.ChannelID, s.message.ID, "📌"))
+// n_pin_messages_were_posted_in adds pin messages to the channel's history, as if they had been posted before the pins
+// were recorded
+func (s *PinStage) n_pin_messages_were_posted_in(n int, name string) *PinStage {
+	for range n {
+		s.pinMessageWasPostedIn(name, nil)
+	}
+
+	return s
 }
 
-func (s *PinStage) the_bot_should_respond_with_message_containing(m string) *PinStage {
-	s.require.Eventually(func() bool {
-		res, err := s.session.InteractionResponse(s.interaction)
-		if err != nil {
-			return false
-		}
+// a_repost_was_posted_in adds a pin message reposted from a pin's record, e.g. by /pins random, to the channel's history
+func (s *PinStage) a_repost_was_posted_in(name string) *PinStage {
+	return s.pinMessageWasPostedIn(name, &discordgo.MessageEmbedFooter{Text: "🔁 Reposted"})
+}
 
-		return strings.Contains(res.Content, m)
-	}, 5*time.Second, 100*time.Millisecond)
+func (s *PinStage) pinMessageWasPostedIn(name string, footer *discordgo.MessageEmbedFooter) *PinStage {
+	c := s.channels[name]
+	sourceID := s.snowflake.Generate().String()
+
+	s.bot.addToHistory(&discordgo.Message{
+		ID:        s.snowflake.Generate().String(),
+		ChannelID: c.ID,
+		GuildID:   testGuildID,
+		Author:    &discordgo.User{ID: testAppID, Username: "Pinbot", Bot: true},
+		Timestamp: time.Now(),
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       "📌 Pinned",
+			URL:         "https://discord.com/channels/" + testGuildID + "/" + s.channel.ID + "/" + sourceID,
+			Description: "Hello, World!",
+			Author:      &discordgo.MessageEmbedAuthor{Name: "author"},
+			Footer:      footer,
+		}},
+	})
 
 	return s
 }
 
-func (s *PinStage) an_attachment(filename, contentType string) *PinStage {
-	f, err := os.Open("files/" + filename)
+// the_backfill_is_resumed resends the backfill command before the message the last backfill stopped at
+func (s *PinStage) the_backfill_is_resumed() *PinStage {
+	res, err := s.session.InteractionResponse(s.interaction)
 	s.require.NoError(err)
-	s.sendMessage.Files = append(s.sendMessage.Files, &discordgo.File{
-		Name:        filename,
-		ContentType: contentType,
-		Reader:      f,
-	})
+
+	_, before, ok := strings.Cut(res.Content, "`before: ")
+	s.require.True(ok, "response should say where to resume")
+	before, _, _ = strings.Cut(before, "`")
+
+	return s.the_pinbot_command_is_sent("backfill", channelOption(s.channels["pins"]), stringOption("before", before))
+}
+
+func (s *PinStage) n_pins_should_be_recorded(n int) *PinStage {
+	pins, err := s.store.ListPins(context.Background(), testGuildID)
+	s.require.NoError(err)
+	s.require.Len(pins, n)
 
 	return s
 }
 
-func (s *PinStage) an_image_attachment() *PinStage {
-	return s.an_attachment("cheese.jpg", "image/jpeg")
+func (s *PinStage) the_guild_has_tags(tags ...string) *PinStage {
+	c, err := s.store.GetGuildConfig(context.Background(), testGuildID)
+	s.require.NoError(err)
+
+	c.Tags = tags
+	s.require.NoError(s.store.PutGuildConfig(context.Background(), c))
+
+	return s
 }
 
-func (s *PinStage) another_image_attachment() *PinStage {
-	return s.an_image_attachment()
+func (s *PinStage) the_guild_posts_on_this_day_in(name string) *PinStage {
+	c, err := s.store.GetGuildConfig(context.Background(), testGuildID)
+	s.require.NoError(err)
+
+	c.OnThisDay = &store.OnThisDay{ChannelID: s.channels[name].ID}
+	s.require.NoError(s.store.PutGuildConfig(context.Background(), c))
+
+	return s
 }
 
-func (s *PinStage) a_file_attachment() *PinStage {
-	return s.an_attachment("hello.txt", "text/plain")
+func (s *PinStage) the_guild_responds_in_mode(mode string) *PinStage {
+	c, err := s.store.GetGuildConfig(context.Background(), testGuildID)
+	s.require.NoError(err)
+
+	c.ResponseMode = mode
+	s.require.NoError(s.store.PutGuildConfig(context.Background(), c))
+
+	return s
 }
 
-func (s *PinStage) the_pin_message_should_have_an_image_embed() {
-	s.the_pin_message_should_have_n_embeds_with_image_url(1)
+func (s *PinStage) the_guild_has_refresh_enabled() *PinStage {
+	c, err := s.store.GetGuildConfig(context.Background(), testGuildID)
+	s.require.NoError(err)
+
+	c.Refresh = true
+	s.require.NoError(s.store.PutGuildConfig(context.Background(), c))
+
+	return s
 }
 
-func (s *PinStage) the_pin_message_should_have_n_embeds_with_image_url(n int) {
-	found := 0
-	for _, embed := range s.pinMessage.Embeds {
-		if embed.Image != nil && embed.Image.URL != "" {
-			found++
-		}
-	}
+func (s *PinStage) the_guild_uses_the_embed_layout() *PinStage {
+	c, err := s.store.GetGuildConfig(context.Background(), testGuildID)
+	s.require.NoError(err)
 
-	s.require.Equal(n, found)
+	c.Template = &store.Template{Layout: store.LayoutEmbed}
+	s.require.NoError(s.store.PutGuildConfig(context.Background(), c))
+
+	return s
+}
+
+func (s *PinStage) the_guild_uses_the_components_layout() *PinStage {
+	c, err := s.store.GetGuildConfig(context.Background(), testGuildID)
+	s.require.NoError(err)
+
+	c.Template = &store.Template{Layout: store.LayoutComponents}
+	s.require.NoError(s.store.PutGuildConfig(context.Background(), c))
+
+	return s
+}
+
+func (s *PinStage) the_guild_posts_a_digest_in(name string) *PinStage {
+	c, err := s.store.GetGuildConfig(context.Background(), testGuildID)
+	s.require.NoError(err)
+
+	c.Digest = &store.Digest{ChannelID: s.channels[name].ID}
+	s.require.NoError(s.store.PutGuildConfig(context.Background(), c))
+
+	return s
+}
+
+// the_guild_has_a_starboard_in scans the channel for messages with at least 3 ⭐ reactions
+func (s *PinStage) the_guild_has_a_starboard_in(name string) *PinStage {
+	c, err := s.store.GetGuildConfig(context.Background(), testGuildID)
+	s.require.NoError(err)
+
+	c.Starboard = &store.Starboard{ChannelIDs: []string{s.channels[name].ID}, Threshold: 3}
+	s.require.NoError(s.store.PutGuildConfig(context.Background(), c))
+
+	return s
+}
+
+// the_message_has_n_reactions reacts to the message in the history the bot reads, as fakediscord doesn't count
+// reactions
+func (s *PinStage) the_message_has_n_reactions(emoji string, n int) *PinStage {
+	return s.the_message_has_a_reaction(&discordgo.MessageReactions{Count: n, Emoji: &discordgo.Emoji{Name: emoji}})
+}
+
+func (s *PinStage) the_bot_has_already_reacted_with(emoji string) *PinStage {
+	return s.the_message_has_a_reaction(&discordgo.MessageReactions{Count: 1, Me: true, Emoji: &discordgo.Emoji{Name: emoji}})
+}
+
+func (s *PinStage) the_message_has_a_reaction(r *discordgo.MessageReactions) *PinStage {
+	s.message.Reactions = append(s.message.Reactions, r)
+	s.bot.addToHistory(s.message)
+
+	return s
+}
+
+func (s *PinStage) the_message_is_edited(content string) *PinStage {
+	m := *s.message
+	m.Content = content
+	s.message = &m
+	s.bot.editMessage(s.message)
+
+	return s
+}
+
+// each_pin_message_should_be_synced_with checks each of the pin's messages shows the content and when it was synced
+func (s *PinStage) each_pin_message_should_be_synced_with(content string) *PinStage {
+	p, err := s.store.GetPin(context.Background(), testGuildID, s.message.ID)
+	s.require.NoError(err)
+	s.require.NotEmpty(p.Targets)
+
+	for _, t := range p.Targets {
+		s.require.Eventually(func() bool {
+			m, ok := s.bot.editedMessage(t.MessageID)
+			if !ok {
+				return false
+			}
+
+			if t.Layout == store.LayoutComponents {
+				bs, err := json.Marshal(s.bot.messageComponents(m.ID))
+				return err == nil && len(m.Embeds) == 0 && strings.Contains(string(bs), content) && strings.Contains(string(bs), "Last synced")
+			}
+
+			return len(m.Embeds) > 0 &&
+				m.Embeds[0].Description == content &&
+				m.Embeds[0].Footer != nil && strings.HasPrefix(m.Embeds[0].Footer.Text, "Last synced")
+		}, 5*time.Second, 100*time.Millisecond, "pin message %s", t.MessageID)
+	}
+
+	return s
+}
+
+func (s *PinStage) the_pin_should_be_recorded_as_synced_with(content string) *PinStage {
+	p, err := s.store.GetPin(context.Background(), testGuildID, s.message.ID)
+	s.require.NoError(err)
+
+	s.require.Equal(content, p.Message.Content)
+	s.require.False(p.SyncedAt.IsZero())
+
+	return s
+}
+
+// the_pin_messages_should_use_the_layout checks the layout each of the pin's messages was recorded in
+func (s *PinStage) the_pin_messages_should_use_the_layout(layout string) *PinStage {
+	p, err := s.store.GetPin(context.Background(), testGuildID, s.message.ID)
+	s.require.NoError(err)
+
+	for _, t := range p.Targets {
+		s.require.Equal(layout, t.Layout)
+	}
+
+	return s
+}
+
+func (s *PinStage) the_message_is_deleted() *PinStage {
+	s.require.NoError(s.session.ChannelMessageDelete(s.message.ChannelID, s.message.ID))
+
+	return s
+}
+
+func (s *PinStage) the_guild_has_native_pins_enabled() *PinStage {
+	c, err := s.store.GetGuildConfig(context.Background(), testGuildID)
+	s.require.NoError(err)
+
+	c.NativePins = true
+	s.require.NoError(s.store.PutGuildConfig(context.Background(), c))
+
+	return s
+}
+
+func (s *PinStage) the_guild_rotates_native_pins() *PinStage {
+	c, err := s.store.GetGuildConfig(context.Background(), testGuildID)
+	s.require.NoError(err)
+
+	c.Rotate = true
+	s.require.NoError(s.store.PutGuildConfig(context.Background(), c))
+
+	return s
+}
+
+// n_messages_were_pinned_natively posts messages in the channel and pins them in Discord's own pin list, in order
+func (s *PinStage) n_messages_were_pinned_natively(n int) *PinStage {
+	for i := range n {
+		m, err := s.session.ChannelMessageSend(s.channel.ID, fmt.Sprintf("Native pin %d", i+1))
+		s.require.NoError(err)
+		m.GuildID = testGuildID
+
+		s.require.True(s.bot.pin(m), "channel pins should not be full")
+		s.nativePins = append(s.nativePins, m)
+	}
+
+	return s
+}
+
+// native_pins_were_archived records the native pins, numbered from the first pinned, as if Pinbot had pinned them
+func (s *PinStage) native_pins_were_archived(ns ...int) *PinStage {
+	for _, n := range ns {
+		m := s.nativePins[n-1]
+
+		s.require.NoError(s.store.PutPin(context.Background(), &store.Pin{
+			GuildID:   testGuildID,
+			ChannelID: m.ChannelID,
+			MessageID: m.ID,
+			Message:   m,
+			PinnedAt:  time.Now(),
+		}))
+	}
+
+	return s
+}
+
+// native_pins_were_marked_as_pinned marks the native pins with the bot's 📌, as if they had been pinned before pins
+// were recorded
+func (s *PinStage) native_pins_were_marked_as_pinned(ns ...int) *PinStage {
+	for _, n := range ns {
+		m := s.nativePins[n-1]
+		m.Reactions = append(m.Reactions, &discordgo.MessageReactions{Count: 1, Me: true, Emoji: &discordgo.Emoji{Name: "📌"}})
+	}
+
+	return s
+}
+
+func (s *PinStage) the_message_should_be_pinned_natively() *PinStage {
+	s.require.True(slices.ContainsFunc(s.bot.pinned(s.message.ChannelID), func(m *discordgo.Message) bool {
+		return m.ID == s.message.ID
+	}))
+
+	return s
+}
+
+func (s *PinStage) the_message_should_not_be_pinned_natively() *PinStage {
+	s.require.False(slices.ContainsFunc(s.bot.pinned(s.message.ChannelID), func(m *discordgo.Message) bool {
+		return m.ID == s.message.ID
+	}))
+
+	return s
+}
+
+func (s *PinStage) n_native_pins_should_remain(n int) *PinStage {
+	s.require.Len(s.bot.pinned(s.channel.ID), n)
+
+	return s
+}
+
+// native_pins_should_be_unpinned checks the native pins, numbered from the first pinned, are no longer pinned
+func (s *PinStage) native_pins_should_be_unpinned(ns ...int) *PinStage {
+	pinned := s.bot.pinned(s.channel.ID)
+
+	for _, n := range ns {
+		s.require.False(slices.ContainsFunc(pinned, func(m *discordgo.Message) bool {
+			return m.ID == s.nativePins[n-1].ID
+		}), "native pin %d should be unpinned", n)
+	}
+
+	return s
+}
+
+// native_pins_should_still_be_pinned checks the native pins, numbered from the first pinned, are still pinned
+func (s *PinStage) native_pins_should_still_be_pinned(from, to int) *PinStage {
+	pinned := s.bot.pinned(s.channel.ID)
+
+	for n := from; n <= to; n++ {
+		s.require.True(slices.ContainsFunc(pinned, func(m *discordgo.Message) bool {
+			return m.ID == s.nativePins[n-1].ID
+		}), "native pin %d should still be pinned", n)
+	}
+
+	return s
+}
+
+// native_pins_should_be_archived checks the native pins, numbered from the first pinned, are recorded
+func (s *PinStage) native_pins_should_be_archived(ns ...int) *PinStage {
+	for _, n := range ns {
+		_, err := s.store.GetPin(context.Background(), testGuildID, s.nativePins[n-1].ID)
+		s.require.NoError(err, "native pin %d should be archived", n)
+	}
+
+	return s
+}
+
+// native_pins_should_not_be_archived checks the native pins, numbered from the first pinned, aren't recorded
+func (s *PinStage) native_pins_should_not_be_archived(ns ...int) *PinStage {
+	for _, n := range ns {
+		_, err := s.store.GetPin(context.Background(), testGuildID, s.nativePins[n-1].ID)
+		s.require.ErrorIs(err, store.ErrNotFound, "native pin %d should not be archived", n)
+	}
+
+	return s
+}
+
+// n_pin_messages_should_be_posted_in checks the number of pin messages posted in the channel during the test
+func (s *PinStage) n_pin_messages_should_be_posted_in(n int, name string) *PinStage {
+	c := s.channels[name]
+
+	count := func() int {
+		return len(slices.DeleteFunc(slices.Clone(s.messages), func(m *discordgo.Message) bool {
+			return m.ChannelID != c.ID || len(m.Embeds) == 0
+		}))
+	}
+
+	s.require.Eventually(func() bool { return count() >= n }, 5*time.Second, 100*time.Millisecond)
+	s.require.Never(func() bool { return count() > n }, 500*time.Millisecond, 100*time.Millisecond)
+
+	return s
+}
+
+// the_message_was_posted_years_ago backdates the message in its pin record, as messages can't be posted in the past
+func (s *PinStage) the_message_was_posted_years_ago(years int) *PinStage {
+	p, err := s.store.GetPin(context.Background(), testGuildID, s.message.ID)
+	s.require.NoError(err)
+
+	p.Message.Timestamp = time.Now().AddDate(-years, 0, 0)
+	s.require.NoError(s.store.PutPin(context.Background(), p))
+
+	return s
+}
+
+// the_scheduled_job_runs sends the event of the job's EventBridge schedule rule
+func (s *PinStage) the_scheduled_job_runs(name string) *PinStage {
+	ctx, _ := xray.BeginSegment(context.Background(), "test")
+
+	s.err = s.job(ctx, events.EventBridgeEvent{
+		DetailType: "Scheduled Event",
+		Source:     "aws.events",
+		Time:       time.Now(),
+		Resources:  []string{"arn:aws:events:eu-west-1:123456789012:rule/pinbot-" + name},
+	})
+	s.require.NoError(s.err)
+
+	return s
+}
+
+// the_recorded_pins_were_posted_years_ago backdates the messages of every pin record
+func (s *PinStage) the_recorded_pins_were_posted_years_ago(years int) *PinStage {
+	pins, err := s.store.ListPins(context.Background(), testGuildID)
+	s.require.NoError(err)
+
+	for _, p := range pins {
+		p.Message.Timestamp = time.Now().AddDate(-years, 0, 0)
+		s.require.NoError(s.store.PutPin(context.Background(), p))
+	}
+
+	return s
+}
+
+func (s *PinStage) no_message_should_be_posted_in(name string) *PinStage {
+	channelID := s.channels[name].ID
+
+	s.require.Never(func() bool {
+		return slices.ContainsFunc(s.messages, func(m *discordgo.Message) bool {
+			return m.ChannelID == channelID
+		})
+	}, time.Second, 100*time.Millisecond)
+
+	return s
+}
+
+func (s *PinStage) no_pin_message_should_be_posted_in(name string) *PinStage {
+	channelID := s.channels[name].ID
+
+	s.require.Never(func() bool {
+		return slices.ContainsFunc(s.messages, func(m *discordgo.Message) bool {
+			return m.ChannelID == channelID && len(m.Embeds) > 0 && m.Embeds[0].Title == "📌 Pinned"
+		})
+	}, time.Second, 100*time.Millisecond)
+
+	return s
+}
+
+// a_message_should_be_posted_in_with_text starts with a text display, as messages using components have no content
+func (s *PinStage) a_message_should_be_posted_in_with_text(name, content string) *PinStage {
+	c := s.channels[name]
+	s.require.NotNil(c)
+
+	s.require.Eventually(func() bool {
+		for _, m := range s.messages {
+			if m.ChannelID != c.ID {
+				continue
+			}
+
+			components := s.bot.messageComponents(m.ID)
+			if len(components) == 0 {
+				continue
+			}
+
+			if t, ok := components[0].(*discordgo.TextDisplay); ok && strings.Contains(t.Content, content) {
+				return true
+			}
+		}
+
+		return false
+	}, 5*time.Second, 100*time.Millisecond)
+
+	return s
+}
+
+func (s *PinStage) a_message_should_be_posted_in_containing(name, content string) *PinStage {
+	c := s.channels[name]
+	s.require.NotNil(c)
+
+	s.require.Eventually(func() bool {
+		for _, m := range s.messages {
+			if m.ChannelID == c.ID && strings.Contains(m.Content, content) {
+				return true
+			}
+		}
+
+		return false
+	}, 5*time.Second, 100*time.Millisecond)
+
+	return s
+}
+
+func (s *PinStage) a_message_should_be_posted_in_with_embed_titled(name, title string) *PinStage {
+	c := s.channels[name]
+	s.require.NotNil(c)
+
+	s.require.Eventually(func() bool {
+		for _, m := range s.messages {
+			if m.ChannelID == c.ID && len(m.Embeds) > 0 && m.Embeds[0].Title == title {
+				return true
+			}
+		}
+
+		return false
+	}, 5*time.Second, 100*time.Millisecond)
+
+	return s
+}
+
+func (s *PinStage) the_pin_is_tagged_with(tags ...string) *PinStage {
+	i := &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    s.snowflake.Generate().String(),
+			AppID: testAppID,
+			Type:  discordgo.InteractionMessageComponent,
+			Data: discordgo.MessageComponentInteractionData{
+				CustomID:      "tag:" + s.message.ID,
+				ComponentType: discordgo.SelectMenuComponent,
+				Values:        tags,
+			},
+			GuildID:   testGuildID,
+			ChannelID: s.message.ChannelID,
+			Member: &discordgo.Member{
+				User: &discordgo.User{
+					ID: s.snowflake.Generate().String(),
+				},
+			},
+			Version: 1,
+		},
+	}
+
+	return s.sendInteraction(i)
+}
+
+func (s *PinStage) the_unpin_button_is_pressed_by_the_pinner() *PinStage {
+	p, err := s.store.GetPin(context.Background(), testGuildID, s.message.ID)
+	s.require.NoError(err)
+
+	return s.unpinButton(p.PinnedByID)
+}
+
+func (s *PinStage) the_unpin_button_is_pressed_by_another_user() *PinStage {
+	return s.unpinButton(s.snowflake.Generate().String())
+}
+
+func (s *PinStage) unpinButton(userID string) *PinStage {
+	i := &discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    s.snowflake.Generate().String(),
+			AppID: testAppID,
+			Type:  discordgo.InteractionMessageComponent,
+			Data: discordgo.MessageComponentInteractionData{
+				CustomID:      "unpin:" + s.message.ID,
+				ComponentType: discordgo.ButtonComponent,
+			},
+			GuildID:   testGuildID,
+			ChannelID: s.message.ChannelID,
+			Member: &discordgo.Member{
+				User:        &discordgo.User{ID: userID},
+				Permissions: s.permissions,
+			},
+			Version: 1,
+		},
+	}
+
+	return s.sendInteraction(i)
+}
+
+func (s *PinStage) the_pin_should_not_be_recorded() *PinStage {
+	_, err := s.store.GetPin(context.Background(), testGuildID, s.message.ID)
+	s.require.ErrorIs(err, store.ErrNotFound)
+
+	return s
+}
+
+func (s *PinStage) the_bot_should_respond_with_a_tag_menu() *PinStage {
+	s.require.Eventually(func() bool {
+		components := s.bot.messageComponents(s.interaction.Token)
+		if len(components) == 0 {
+			return false
+		}
+
+		row, ok := components[0].(*discordgo.ActionsRow)
+		if !ok || len(row.Components) == 0 {
+			return false
+		}
+
+		menu, ok := row.Components[0].(*discordgo.SelectMenu)
+
+		return ok && menu.CustomID == "tag:"+s.message.ID
+	}, 5*time.Second, 100*time.Millisecond)
+
+	return s
+}
+
+func (s *PinStage) the_pin_should_be_recorded_with_tags(tags ...string) *PinStage {
+	p, err := s.store.GetPin(context.Background(), testGuildID, s.message.ID)
+	s.require.NoError(err)
+	s.require.Equal(tags, p.Tags)
+
+	return s
+}
+
+func channelOption(c *discordgo.Channel) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{
+		Name:  "channel",
+		Type:  discordgo.ApplicationCommandOptionChannel,
+		Value: c.ID,
+	}
+}
+
+func stringOption(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{
+		Name:  name,
+		Type:  discordgo.ApplicationCommandOptionString,
+		Value: value,
+	}
+}
+
+func (s *PinStage) a_pin_message_should_be_posted_in(name string) *PinStage {
+	c := s.channels[name]
+	s.require.NotNil(c)
+
+	s.require.Eventually(func() bool {
+		for _, m := range s.messages {
+			if m.ChannelID != c.ID {
+				continue
+			}
+
+			for _, embed := range m.Embeds {
+				if embed.Title == "📌 Pinned" && strings.Contains(embed.Description, s.sendMessage.Content) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}, 5*time.Second, 100*time.Millisecond)
+
+	return s
+}
+
+func (s *PinStage) the_pin_should_be_recorded_with_n_targets(n int) *PinStage {
+	s.require.Eventually(func() bool {
+		p, err := s.store.GetPin(context.Background(), testGuildID, s.message.ID)
+		if err != nil {
+			return false
+		}
+
+		return len(p.Targets) == n
+	}, 5*time.Second, 100*time.Millisecond)
+
+	return s
+}
+
+func (s *PinStage) the_bot_should_add_the_emoji(emoji string) *PinStage {
+	s.require.Eventually(func() bool {
+		reactions, err := s.session.MessageReactions(s.channel.ID, s.message.ID, emoji, 0, "", "")
+		if err != nil {
+			return false
+		}
+
+		for _, r := range reactions {
+			if r.ID == s.session.State.User.ID {
+				return true
+			}
+		}
+
+		return false
+	}, 5*time.Second, 500*time.Millisecond)
+
+	return s
+}
+
+func (s *PinStage) handleMessageFor(channelID string) func(*discordgo.Session, *discordgo.MessageCreate) {
+	return func(_ *discordgo.Session, m *discordgo.MessageCreate) {
+		if m.ChannelID == channelID {
+			s.messages = append(s.messages, m.Message)
+		}
+	}
+}
+
+func (s *PinStage) the_message_is_already_marked_as_pinned() {
+	s.require.NoError(s.session.MessageReactionAdd(s.message.ChannelID, s.message.ID, "📌"))
+}
+
+func (s *PinStage) the_bot_should_respond_with_message_containing(m string) *PinStage {
+	s.require.Eventually(func() bool {
+		res, err := s.session.InteractionResponse(s.interaction)
+		if err != nil {
+			return false
+		}
+
+		return strings.Contains(res.Content, m)
+	}, 5*time.Second, 100*time.Millisecond)
+
+	return s
+}
+
+// the_response_should_stay_private checks the response was only shown to the member, which it is as long as the bot
+// edits the deferred response, as interactions are deferred ephemerally, rather than deleting it or following it up
+func (s *PinStage) the_response_should_stay_private() *PinStage {
+	s.no_followup_should_be_sent()
+	s.require.False(s.bot.response(s.interaction.Token).deleted, "response should not be deleted")
+
+	return s
+}
+
+func (s *PinStage) the_response_should_be_deleted() *PinStage {
+	s.require.Eventually(func() bool {
+		return s.bot.response(s.interaction.Token).deleted
+	}, 5*time.Second, 100*time.Millisecond)
+
+	return s
+}
+
+func (s *PinStage) the_bot_should_follow_up_publicly_with_message_containing(content string) *PinStage {
+	s.require.Eventually(func() bool {
+		for _, m := range s.bot.response(s.interaction.Token).followups {
+			if strings.Contains(m.Content, content) {
+				s.require.Zero(m.Flags&discordgo.MessageFlagsEphemeral, "followup should be public")
+				return true
+			}
+		}
+
+		return false
+	}, 5*time.Second, 100*time.Millisecond)
+
+	return s
+}
+
+// no_followup_should_be_sent checks nothing about the pin is shown to the whole channel
+func (s *PinStage) no_followup_should_be_sent() *PinStage {
+	s.require.Never(func() bool {
+		return len(s.bot.response(s.interaction.Token).followups) > 0
+	}, time.Second, 100*time.Millisecond)
+
+	return s
+}
+
+// the_next_page_of_search_results_is_requested presses the next page button of the search results
+func (s *PinStage) the_next_page_of_search_results_is_requested() *PinStage {
+	components := s.bot.messageComponents(s.interaction.Token)
+	s.require.NotEmpty(components)
+
+	row, ok := components[0].(*discordgo.ActionsRow)
+	s.require.True(ok)
+	s.require.Len(row.Components, 2)
+
+	next, ok := row.Components[1].(*discordgo.Button)
+	s.require.True(ok)
+
+	return s.sendInteraction(&discordgo.InteractionCreate{
+		Interaction: &discordgo.Interaction{
+			ID:    s.snowflake.Generate().String(),
+			AppID: testAppID,
+			Type:  discordgo.InteractionMessageComponent,
+			Data: discordgo.MessageComponentInteractionData{
+				CustomID:      next.CustomID,
+				ComponentType: discordgo.ButtonComponent,
+			},
+			GuildID:   testGuildID,
+			ChannelID: s.channel.ID,
+			Member: &discordgo.Member{
+				User: &discordgo.User{
+					ID: s.snowflake.Generate().String(),
+				},
+			},
+			Version: 1,
+		},
+	})
+}
+
+func (s *PinStage) the_bot_should_respond_with_embed_footer(footer string) *PinStage {
+	s.require.Eventually(func() bool {
+		res, err := s.session.InteractionResponse(s.interaction)
+		if err != nil {
+			return false
+		}
+
+		for _, e := range res.Embeds {
+			if e.Footer != nil && e.Footer.Text == footer {
+				return true
+			}
+		}
+
+		return false
+	}, 5*time.Second, 100*time.Millisecond)
+
+	return s
+}
+
+func (s *PinStage) the_bot_should_respond_with_embed_titled(title string) *PinStage {
+	s.require.Eventually(func() bool {
+		res, err := s.session.InteractionResponse(s.interaction)
+		if err != nil {
+			return false
+		}
+
+		for _, e := range res.Embeds {
+			if e.Title == title {
+				return true
+			}
+		}
+
+		return false
+	}, 5*time.Second, 100*time.Millisecond)
+
+	return s
+}
+
+func (s *PinStage) the_response_should_not_mention_the_channel(name string) *PinStage {
+	res, err := s.session.InteractionResponse(s.interaction)
+	s.require.NoError(err)
+
+	bs, err := json.Marshal(res)
+	s.require.NoError(err)
+	s.require.NotContains(string(bs), s.channels[name].Mention())
+
+	return s
+}
+
+func (s *PinStage) an_attachment(filename, contentType string) *PinStage {
+	f, err := os.Open("files/" + filename)
+	s.require.NoError(err)
+	s.sendMessage.Files = append(s.sendMessage.Files, &discordgo.File{
+		Name:        filename,
+		ContentType: contentType,
+		Reader:      f,
+	})
+
+	return s
+}
+
+func (s *PinStage) an_image_attachment() *PinStage {
+	return s.an_attachment("cheese.jpg", "image/jpeg")
+}
+
+func (s *PinStage) another_image_attachment() *PinStage {
+	return s.an_image_attachment()
+}
+
+func (s *PinStage) a_file_attachment() *PinStage {
+	return s.an_attachment("hello.txt", "text/plain")
+}
+
+func (s *PinStage) the_pin_message_should_have_an_image_embed() {
+	s.the_pin_message_should_have_n_embeds_with_image_url(1)
+}
+
+func (s *PinStage) the_pin_message_should_have_n_embeds_with_image_url(n int) {
+	found := 0
+	for _, embed := range s.pinMessage.Embeds {
+		if embed.Image != nil && embed.Image.URL != "" {
+			found++
+		}
+	}
+
+	s.require.Equal(n, found)
+}
+
+func (s *PinStage) the_pin_message_should_have_n_rehosted_images(n int) *PinStage {
+	s.require.Len(s.pinMessage.Attachments, n)
+
+	for _, embed := range s.pinMessage.Embeds {
+		if embed.Image != nil {
+			s.require.True(strings.HasPrefix(embed.Image.URL, "attachment://"), "image should reference the pin message's attachment")
+		}
+	}
+
+	return s
 }
 
 func (s *PinStage) the_pin_message_should_have_n_embeds(n int) *PinStage {
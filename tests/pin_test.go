@@ -52,6 +52,312 @@ func TestPinSpecificPinsChannel(t *testing.T) {
 		the_bot_should_successfully_acknowledge_the_pin()
 }
 
+func TestPinMultipleDestinations(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_channel_named("test-pins").and().
+		a_destination_channel_named("hall-of-fame").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		a_pin_message_should_be_posted_in("test-pins").and().
+		a_pin_message_should_be_posted_in("hall-of-fame").and().
+		the_bot_should_successfully_acknowledge_the_pin().and().
+		the_pin_should_be_recorded_with_n_targets(2)
+}
+
+func TestPinMultipleDestinationsPartialFailure(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_channel_named("test-pins").and().
+		a_destination_channel_named("hall-of-fame").and().
+		the_bot_cannot_post_in("hall-of-fame").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		a_pin_message_should_be_posted_in("test-pins").and().
+		the_bot_should_successfully_acknowledge_the_pin().and().
+		the_bot_should_respond_with_message_containing("Could not send pin message").and().
+		the_pin_should_be_recorded_with_n_targets(1)
+}
+
+func TestPinMirror(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_mirror_channel_named("archive").and().
+		the_mirror_guild_accepts_pins_from_the_guild().and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		a_pin_message_should_be_posted_in("test").and().
+		a_pin_message_should_be_posted_in("archive").and().
+		the_bot_should_successfully_acknowledge_the_pin().and().
+		the_pin_should_be_recorded_with_n_targets(2)
+}
+
+func TestPinMirrorNotAccepted(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_mirror_channel_named("archive").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		a_pin_message_should_be_posted_in("test").and().
+		the_bot_should_respond_with_message_containing("Could not mirror pin message").and().
+		no_pin_message_should_be_posted_in("archive").and().
+		the_pin_should_be_recorded_with_n_targets(1)
+}
+
+func TestPinMirrorPrivateChannel(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("pins").and().
+		a_private_channel_named("mods").and().
+		a_mirror_channel_named("archive").and().
+		the_mirror_guild_accepts_pins_from_the_guild().and().
+		the_message_is_posted_in("mods")
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		a_pin_message_should_be_posted_in("pins").and().
+		the_bot_should_respond_with_message_containing("Could not mirror pin message").and().
+		no_pin_message_should_be_posted_in("archive").and().
+		the_pin_should_be_recorded_with_n_targets(1)
+}
+
+func TestPinMirrorWithoutPermission(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_mirror_channel_the_bot_cannot_post_in_named("archive").and().
+		the_mirror_guild_accepts_pins_from_the_guild().and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		a_pin_message_should_be_posted_in("test").and().
+		the_bot_should_respond_with_message_containing("Could not mirror pin message").and().
+		no_pin_message_should_be_posted_in("archive").and().
+		the_pin_should_be_recorded_with_n_targets(1)
+}
+
+func TestPinRespondsPublicly(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_guild_responds_in_mode("public").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		a_pin_message_should_be_posted_in_the_last_channel().and().
+		the_bot_should_add_the_emoji("📌").and().
+		the_bot_should_follow_up_publicly_with_message_containing("📌 Pinned").and().
+		the_response_should_be_deleted()
+}
+
+func TestPinRespondsPubliclyWithPrivateFailures(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_channel_named("test-pins").and().
+		a_destination_channel_named("hall-of-fame").and().
+		the_bot_cannot_post_in("hall-of-fame").and().
+		the_guild_responds_in_mode("public").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		a_pin_message_should_be_posted_in("test-pins").and().
+		the_bot_should_follow_up_publicly_with_message_containing("📌 Pinned").and().
+		the_bot_should_respond_with_message_containing("Could not send pin message")
+}
+
+func TestPinRespondsSilently(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_guild_responds_in_mode("silent").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		a_pin_message_should_be_posted_in_the_last_channel().and().
+		the_bot_should_add_the_emoji("📌").and().
+		the_response_should_be_deleted().and().
+		no_followup_should_be_sent()
+}
+
+func TestPinErrorsRespondPrivately(t *testing.T) {
+	for _, mode := range []string{"ephemeral", "public", "silent"} {
+		t.Run(mode, func(t *testing.T) {
+			given, when, then := NewPinStage(t)
+
+			given.
+				a_channel_named("test").and().
+				the_bot_cannot_post_in("test").and().
+				the_guild_responds_in_mode(mode).and().
+				the_message_is_posted()
+
+			when.
+				the_pin_command_is_sent_for_the_message()
+
+			then.
+				the_bot_should_respond_with_message_containing("Could not send pin message").and().
+				the_response_should_stay_private()
+		})
+	}
+}
+
+func TestPinToChannel(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_channel_named("test-pins").and().
+		a_channel_named("archive").and().
+		the_message_is_posted()
+
+	when.
+		a_channel_is_chosen_from_the_pin_to_menu("archive")
+
+	then.
+		a_pin_message_should_be_posted_in("archive").and().
+		the_bot_should_successfully_acknowledge_the_pin().and().
+		the_pin_should_be_recorded_with_n_targets(1)
+}
+
+func TestPinToChannelWithoutPermission(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_private_channel_named("mods").and().
+		the_message_is_posted()
+
+	when.
+		a_channel_is_chosen_from_the_pin_to_menu("mods")
+
+	then.
+		the_bot_should_respond_with_message_containing("Please ensure bot has permission to post in").and().
+		the_pin_should_not_be_recorded()
+}
+
+func TestPinWithNote(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_with_note_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_respond_with_a_modal()
+
+	when.
+		a_note_is_submitted_for_the_message("context: this was after the outage")
+
+	then.
+		a_pin_message_should_be_posted_in_the_last_channel().and().
+		the_bot_should_successfully_acknowledge_the_pin().and().
+		the_pin_message_should_have_a_field("Note", "context: this was after the outage")
+}
+
+func TestPinWithTags(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_guild_has_tags("funny", "important", "lore").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		a_pin_message_should_be_posted_in_the_last_channel().and().
+		the_bot_should_successfully_acknowledge_the_pin().and().
+		the_bot_should_respond_with_a_tag_menu()
+
+	when.
+		the_pin_is_tagged_with("funny", "lore", "unknown")
+
+	then.
+		the_bot_should_respond_with_message_containing("🏷️ Tagged: funny, lore").and().
+		the_pin_should_be_recorded_with_tags("funny", "lore")
+}
+
+func TestUnpin(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_message_is_posted().and().
+		the_pin_command_is_sent_for_the_message().and().
+		the_pin_should_be_recorded_with_n_targets(1)
+
+	when.
+		the_unpin_button_is_pressed_by_the_pinner()
+
+	then.
+		the_bot_should_respond_with_message_containing("🗑️ Unpinned").and().
+		the_pin_should_not_be_recorded()
+}
+
+func TestUnpinNotPinner(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_message_is_posted().and().
+		the_pin_command_is_sent_for_the_message().and().
+		the_pin_should_be_recorded_with_n_targets(1)
+
+	when.
+		the_unpin_button_is_pressed_by_another_user()
+
+	then.
+		the_bot_should_respond_with_message_containing("🙅 Only the member who pinned this message").and().
+		the_pin_should_be_recorded_with_n_targets(1)
+}
+
 func TestPinAlreadyPinned(t *testing.T) {
 	given, when, then := NewPinStage(t)
 
@@ -84,6 +390,7 @@ func TestPinWithImage(t *testing.T) {
 		a_pin_message_should_be_posted_in_the_last_channel().and().
 		the_bot_should_successfully_acknowledge_the_pin().and().
 		the_pin_message_should_have_n_embeds(1).and().
+		the_pin_message_should_have_n_rehosted_images(1).and().
 		the_pin_message_should_have_an_image_embed()
 }
 
@@ -104,6 +411,7 @@ func TestPinWithMultipleImage(t *testing.T) {
 		a_pin_message_should_be_posted_in_the_last_channel().and().
 		the_bot_should_successfully_acknowledge_the_pin().and().
 		the_pin_message_should_have_n_embeds(2).and().
+		the_pin_message_should_have_n_rehosted_images(2).and().
 		the_pin_message_should_have_n_embeds_with_image_url(2)
 }
 
@@ -144,3 +452,150 @@ func TestPinPersistsEmbeds(t *testing.T) {
 		a_pin_message_should_be_posted_in_the_last_channel().and().
 		the_pin_message_should_have_n_embeds(2) // the pin embed + link
 }
+
+func TestRefreshPin(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_destination_channel_named("hall-of-fame").and().
+		the_guild_has_refresh_enabled().and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_successfully_acknowledge_the_pin().and().
+		the_pin_should_be_recorded_with_n_targets(2)
+
+	given.
+		the_message_is_edited("Hello, edited World!")
+
+	when.
+		the_refresh_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_respond_with_message_containing("🔄 Refreshed").and().
+		each_pin_message_should_be_synced_with("Hello, edited World!").and().
+		the_pin_should_be_recorded_as_synced_with("Hello, edited World!")
+
+	given.
+		the_message_is_edited("Hello, edited again World!")
+
+	when.
+		the_refresh_pin_command_is_sent_for_the_pin_message()
+
+	then.
+		the_bot_should_respond_with_message_containing("🔄 Refreshed").and().
+		each_pin_message_should_be_synced_with("Hello, edited again World!").and().
+		the_pin_should_be_recorded_as_synced_with("Hello, edited again World!")
+}
+
+func TestRefreshPinKeepsLayout(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_guild_uses_the_components_layout().and().
+		the_guild_has_refresh_enabled().and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_successfully_acknowledge_the_pin().and().
+		the_pin_messages_should_use_the_layout("components")
+
+	given.
+		the_guild_uses_the_embed_layout().and().
+		the_message_is_edited("Hello, edited World!")
+
+	when.
+		the_refresh_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_respond_with_message_containing("🔄 Refreshed").and().
+		each_pin_message_should_be_synced_with("Hello, edited World!").and().
+		the_pin_messages_should_use_the_layout("components")
+}
+
+func TestRefreshPinNotEnabled(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_successfully_acknowledge_the_pin()
+
+	when.
+		the_refresh_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_respond_with_message_containing("🙅 Refreshing pins is not enabled")
+}
+
+func TestPinNatively(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_guild_has_native_pins_enabled().and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_successfully_acknowledge_the_pin().and().
+		the_message_should_be_pinned_natively()
+}
+
+func TestPinNativelyUnpinsOldestArchived(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_guild_has_native_pins_enabled().and().
+		n_messages_were_pinned_natively(50).and().
+		native_pins_were_archived(10, 20).and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_successfully_acknowledge_the_pin().and().
+		the_message_should_be_pinned_natively().and().
+		native_pins_should_be_unpinned(10).and().
+		native_pins_should_still_be_pinned(1, 9).and().
+		native_pins_should_still_be_pinned(11, 50).and().
+		n_native_pins_should_remain(50)
+}
+
+func TestPinNativelyNoneArchived(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_guild_has_native_pins_enabled().and().
+		n_messages_were_pinned_natively(50).and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_successfully_acknowledge_the_pin().and().
+		the_bot_should_respond_with_message_containing("🙅 Could not add to the channel's pins").and().
+		the_pin_should_be_recorded_with_n_targets(1).and().
+		the_message_should_not_be_pinned_natively().and().
+		native_pins_should_still_be_pinned(1, 50).and().
+		n_native_pins_should_remain(50)
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPinbotBackfillRequiresManageServer(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("pins")
+
+	when.
+		the_pinbot_command_is_sent("backfill", &discordgo.ApplicationCommandInteractionDataOption{
+			Name:  "channel",
+			Type:  discordgo.ApplicationCommandOptionChannel,
+			Value: given.channels["pins"].ID,
+		})
+
+	then.
+		the_bot_should_respond_with_message_containing("🙅 Only members who can manage the server")
+}
+
+func TestPinbotBackfill(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_channel_named("pins").and().
+		n_pin_messages_were_posted_in(2, "pins").and().
+		a_repost_was_posted_in("pins").and().
+		the_user_can_manage_the_server()
+
+	when.
+		the_pinbot_command_is_sent("backfill", channelOption(given.channels["pins"]))
+
+	then.
+		the_bot_should_respond_with_message_containing("📥 Indexed 2 pins").and().
+		n_pins_should_be_recorded(2)
+}
+
+func TestPinbotBackfillResumes(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_channel_named("pins").and().
+		n_pin_messages_were_posted_in(1001, "pins").and().
+		the_user_can_manage_the_server()
+
+	when.
+		the_pinbot_command_is_sent("backfill", channelOption(given.channels["pins"]))
+
+	then.
+		the_bot_should_respond_with_message_containing("📥 Indexed 1000 pins").and().
+		the_bot_should_respond_with_message_containing("⏭️ Stopped after reading 1000 messages").and().
+		n_pins_should_be_recorded(1000)
+
+	when.
+		the_backfill_is_resumed()
+
+	then.
+		the_bot_should_respond_with_message_containing("📥 Indexed 1 pin from").and().
+		n_pins_should_be_recorded(1001)
+}
+
+func TestPinbotVerify(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_successfully_acknowledge_the_pin()
+
+	given.
+		the_message_is_deleted().and().
+		the_user_can_manage_the_server()
+
+	when.
+		the_pinbot_command_is_sent("verify")
+
+	then.
+		the_bot_should_respond_with_message_containing("🔍 Checked 1 pin, found 1 with deleted originals")
+}
@@ -0,0 +1,311 @@
+package tests
+
+import (
+	"testing"
+)
+
+func TestPinsSearch(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_successfully_acknowledge_the_pin()
+
+	when.
+		the_pins_command_is_sent("search", stringOption("query", "hello"))
+
+	then.
+		the_bot_should_respond_with_embed_titled("🔍 1 pin found")
+}
+
+func TestPinsSearchNoResults(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_successfully_acknowledge_the_pin()
+
+	when.
+		the_pins_command_is_sent("search", stringOption("query", "goodbye"))
+
+	then.
+		the_bot_should_respond_with_embed_titled("🔍 0 pins found")
+}
+
+func TestPinsSearchPrivateChannel(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_private_channel_named("mods").and().
+		n_pins_were_recorded_in(1, "test").and().
+		n_pins_were_recorded_in(1, "mods")
+
+	when.
+		the_pins_command_is_sent("search", stringOption("query", "hello"))
+
+	then.
+		the_bot_should_respond_with_embed_titled("🔍 1 pin found")
+}
+
+func TestPinsSearchPages(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		n_pins_were_recorded_in(11, "test")
+
+	when.
+		the_pins_command_is_sent("search", stringOption("query", "hello"))
+
+	then.
+		the_bot_should_respond_with_embed_titled("🔍 11 pins found").and().
+		the_bot_should_respond_with_embed_footer("Page 1 of 2")
+
+	when.
+		the_next_page_of_search_results_is_requested()
+
+	then.
+		the_bot_should_respond_with_embed_footer("Page 2 of 2")
+}
+
+func TestPinsSearchTooManyResults(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		n_pins_were_recorded_in(101, "test")
+
+	when.
+		the_pins_command_is_sent("search", stringOption("query", "hello"))
+
+	then.
+		the_bot_should_respond_with_embed_titled("🔍 100 pins found").and().
+		the_bot_should_respond_with_embed_footer("Page 1 of 10 • Narrow your search to see more results")
+}
+
+func TestPinsSearchInvalidDate(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test")
+
+	when.
+		the_pins_command_is_sent("search", stringOption("from", "yesterday"))
+
+	then.
+		the_bot_should_respond_with_message_containing("🙅 Invalid date")
+}
+
+func TestPinsRandom(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_successfully_acknowledge_the_pin()
+
+	when.
+		the_pins_command_is_sent("random")
+
+	then.
+		the_bot_should_respond_with_message_containing("🎲 Posted")
+}
+
+func TestPinsRandomPrivateChannel(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_private_channel_named("mods").and().
+		n_pins_were_recorded_in(1, "mods")
+
+	when.
+		the_pins_command_is_sent("random")
+
+	then.
+		the_bot_should_respond_with_message_containing("🙅 No pins found")
+}
+
+func TestPinsRandomNoPins(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test")
+
+	when.
+		the_pins_command_is_sent("random")
+
+	then.
+		the_bot_should_respond_with_message_containing("🙅 No pins found")
+}
+
+func TestPinsStats(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_successfully_acknowledge_the_pin()
+
+	when.
+		the_pins_command_is_sent("stats")
+
+	then.
+		the_bot_should_respond_with_embed_titled("📊 1 pin")
+}
+
+func TestPinsStatsPrivateChannel(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_private_channel_named("mods").and().
+		n_pins_were_recorded_in(2, "test").and().
+		n_pins_were_recorded_in(1, "mods")
+
+	when.
+		the_pins_command_is_sent("stats")
+
+	then.
+		the_bot_should_respond_with_embed_titled("📊 2 pins").and().
+		the_response_should_not_mention_the_channel("mods")
+}
+
+func TestPinsExport(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_successfully_acknowledge_the_pin()
+
+	given.
+		the_user_can_manage_the_server()
+
+	when.
+		the_pins_command_is_sent("export", stringOption("format", "json"))
+
+	then.
+		the_bot_should_respond_with_message_containing("📦 Exported 1 pin")
+}
+
+func TestPinsExportHTML(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_successfully_acknowledge_the_pin()
+
+	given.
+		the_user_can_manage_the_server()
+
+	when.
+		the_pins_command_is_sent("export", stringOption("format", "html"))
+
+	then.
+		the_bot_should_respond_with_message_containing("📦 Exported 1 pin")
+}
+
+func TestPinsExportRequiresManageServer(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test")
+
+	when.
+		the_pins_command_is_sent("export", stringOption("format", "json"))
+
+	then.
+		the_bot_should_respond_with_message_containing("🙅 Only members who can manage the server")
+}
+
+func TestPinsRotateNothingToRotate(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_user_can_manage_the_server()
+
+	when.
+		the_pins_command_is_sent("rotate")
+
+	then.
+		the_bot_should_respond_with_message_containing("♻️ Nothing to rotate")
+}
+
+func TestPinsRotate(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_channel_named("pins").and().
+		the_user_can_manage_the_server().and().
+		n_messages_were_pinned_natively(46).and().
+		native_pins_were_archived(2).and().
+		native_pins_were_marked_as_pinned(3)
+
+	when.
+		the_pins_command_is_sent("rotate")
+
+	then.
+		the_bot_should_respond_with_message_containing("♻️ Rotated 6 pins in").and().
+		native_pins_should_be_archived(1, 4, 5, 6).and().
+		native_pins_should_not_be_archived(3).and().
+		n_pin_messages_should_be_posted_in(4, "pins").and().
+		native_pins_should_be_unpinned(1, 2, 3, 4, 5, 6).and().
+		native_pins_should_still_be_pinned(7, 46).and().
+		n_native_pins_should_remain(40)
+}
+
+func TestPinsRotateBelowThreshold(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_channel_named("pins").and().
+		the_user_can_manage_the_server().and().
+		n_messages_were_pinned_natively(44)
+
+	when.
+		the_pins_command_is_sent("rotate")
+
+	then.
+		the_bot_should_respond_with_message_containing("♻️ Nothing to rotate").and().
+		n_pins_should_be_recorded(0).and().
+		native_pins_should_still_be_pinned(1, 44).and().
+		n_native_pins_should_remain(44)
+}
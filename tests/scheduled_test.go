@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"testing"
+)
+
+func TestOnThisDay(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_guild_posts_on_this_day_in("test").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_successfully_acknowledge_the_pin()
+
+	given.
+		the_message_was_posted_years_ago(2)
+
+	when.
+		the_scheduled_job_runs("on-this-day")
+
+	then.
+		a_message_should_be_posted_in_containing("test", "📅 On this day in")
+}
+
+func TestOnThisDayComponents(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_guild_uses_the_components_layout().and().
+		the_guild_posts_on_this_day_in("test").and().
+		n_pins_were_recorded_in(1, "test").and().
+		the_recorded_pins_were_posted_years_ago(2)
+
+	when.
+		the_scheduled_job_runs("on-this-day")
+
+	then.
+		a_message_should_be_posted_in_with_text("test", "📅 On this day in")
+}
+
+func TestOnThisDayPrivateChannel(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_private_channel_named("mods").and().
+		the_guild_posts_on_this_day_in("test").and().
+		n_pins_were_recorded_in(1, "mods").and().
+		the_recorded_pins_were_posted_years_ago(2)
+
+	when.
+		the_scheduled_job_runs("on-this-day")
+
+	then.
+		no_message_should_be_posted_in("test")
+}
+
+func TestDigest(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		the_guild_posts_a_digest_in("test").and().
+		the_message_is_posted()
+
+	when.
+		the_pin_command_is_sent_for_the_message()
+
+	then.
+		the_bot_should_successfully_acknowledge_the_pin()
+
+	when.
+		the_scheduled_job_runs("digest")
+
+	then.
+		a_message_should_be_posted_in_with_embed_titled("test", "🗞️ Weekly pin digest")
+}
+
+func TestDigestPrivateChannel(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_private_channel_named("mods").and().
+		the_guild_posts_a_digest_in("test").and().
+		n_pins_were_recorded_in(1, "mods")
+
+	when.
+		the_scheduled_job_runs("digest")
+
+	then.
+		no_message_should_be_posted_in("test")
+}
+
+func TestStarboard(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("starboard").and().
+		the_guild_has_a_starboard_in("starboard").and().
+		the_message_is_posted().and().
+		the_message_has_n_reactions("⭐", 3)
+
+	when.
+		the_scheduled_job_runs("starboard")
+
+	then.
+		a_pin_message_should_be_posted_in_the_last_channel().and().
+		the_pin_message_should_have_a_field("Starboard", "⭐ 3").and().
+		the_bot_should_add_the_emoji("📌")
+}
+
+func TestStarboardBelowThreshold(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("starboard").and().
+		the_guild_has_a_starboard_in("starboard").and().
+		the_message_is_posted().and().
+		the_message_has_n_reactions("⭐", 2)
+
+	when.
+		the_scheduled_job_runs("starboard")
+
+	then.
+		no_pin_message_should_be_posted_in("starboard")
+}
+
+func TestStarboardAlreadyPinned(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("starboard").and().
+		the_guild_has_a_starboard_in("starboard").and().
+		the_message_is_posted().and().
+		the_message_has_n_reactions("⭐", 3).and().
+		the_bot_has_already_reacted_with("📌")
+
+	when.
+		the_scheduled_job_runs("starboard")
+
+	then.
+		no_pin_message_should_be_posted_in("starboard")
+}
+
+func TestRotate(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_channel_named("pins").and().
+		the_guild_rotates_native_pins().and().
+		n_messages_were_pinned_natively(45).and().
+		native_pins_were_marked_as_pinned(1)
+
+	when.
+		the_scheduled_job_runs("rotate")
+
+	then.
+		native_pins_should_be_archived(2, 3, 4, 5).and().
+		native_pins_should_not_be_archived(1).and().
+		n_pin_messages_should_be_posted_in(4, "pins").and().
+		native_pins_should_be_unpinned(1, 2, 3, 4, 5).and().
+		native_pins_should_still_be_pinned(6, 45).and().
+		n_native_pins_should_remain(40)
+}
+
+func TestRotateNotEnabled(t *testing.T) {
+	given, when, then := NewPinStage(t)
+
+	given.
+		a_channel_named("test").and().
+		a_channel_named("pins").and().
+		n_messages_were_pinned_natively(46)
+
+	when.
+		the_scheduled_job_runs("rotate")
+
+	then.
+		n_pins_should_be_recorded(0).and().
+		n_native_pins_should_remain(46)
+}
@@ -0,0 +1,660 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// transport sits between the bot and fakediscord, simulating the failures which fakediscord can't, and keeping the
+// parts of requests which fakediscord can't parse
+type transport struct {
+	mu sync.Mutex
+
+	// denied are the IDs of the channels the bot is missing permission to post in
+	denied map[string]bool
+
+	// members are the guild members fakediscord can't return, by user ID
+	members map[string]*discordgo.Member
+
+	// components are the components sent with each message, by message ID or by interaction token for responses.
+	// fakediscord can't decode components, so they are removed from requests before they are forwarded.
+	components map[string]json.RawMessage
+
+	// history is the message history of each channel, oldest first, by channel ID. fakediscord can't list a
+	// channel's messages, nor does it count their reactions.
+	history map[string][]*discordgo.Message
+
+	// responses are the interaction responses, by interaction token. fakediscord doesn't implement followups or
+	// deleting responses.
+	responses map[string]*interactionResponse
+
+	// edited are the messages which have been edited, by message ID. fakediscord can't edit messages, so they are
+	// returned in place of fakediscord's.
+	edited map[string]*discordgo.Message
+
+	// pins are the native pins of each channel, most recently pinned first as Discord lists them, by channel ID.
+	// fakediscord can't unpin messages, nor does it limit how many can be pinned.
+	pins map[string][]*discordgo.Message
+}
+
+// maxPins is the number of messages Discord allows to be pinned in a channel
+const maxPins = 50
+
+// interactionResponse is what the bot sent in response to an interaction, besides the initial response
+type interactionResponse struct {
+	// deleted is true if the initial response was deleted
+	deleted bool
+
+	// followups are the followup messages sent after the initial response
+	followups []*discordgo.Message
+}
+
+func newTransport() *transport {
+	return &transport{
+		denied:     map[string]bool{},
+		members:    map[string]*discordgo.Member{},
+		components: map[string]json.RawMessage{},
+		history:    map[string][]*discordgo.Message{},
+		responses:  map[string]*interactionResponse{},
+		edited:     map[string]*discordgo.Message{},
+		pins:       map[string][]*discordgo.Message{},
+	}
+}
+
+func (t *transport) deny(channelID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.denied[channelID] = true
+}
+
+func (t *transport) addMember(m *discordgo.Member) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.members[m.User.ID] = m
+}
+
+// response returns a copy of what the bot sent in response to the interaction with the token
+func (t *transport) response(token string) interactionResponse {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if r, ok := t.responses[token]; ok {
+		return *r
+	}
+
+	return interactionResponse{}
+}
+
+// updateResponse updates the response to the interaction with the token
+func (t *transport) updateResponse(token string, f func(r *interactionResponse)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r, ok := t.responses[token]
+	if !ok {
+		r = &interactionResponse{}
+		t.responses[token] = r
+	}
+
+	f(r)
+}
+
+// addToHistory adds the message to its channel's history, replacing it if it's already there
+func (t *transport) addToHistory(m *discordgo.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	history := slices.DeleteFunc(t.history[m.ChannelID], func(h *discordgo.Message) bool {
+		return h.ID == m.ID
+	})
+
+	t.history[m.ChannelID] = append(history, m)
+}
+
+// editMessage replaces the message with its edited version
+func (t *transport) editMessage(m *discordgo.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.edited[m.ID] = m
+}
+
+// editedMessage returns the message if it has been edited
+func (t *transport) editedMessage(messageID string) (*discordgo.Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m, ok := t.edited[messageID]
+
+	return m, ok
+}
+
+// pin pins the message natively, failing as Discord does if the channel's pins are full
+func (t *transport) pin(m *discordgo.Message) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.pins[m.ChannelID]) >= maxPins {
+		return false
+	}
+
+	t.pins[m.ChannelID] = slices.Insert(slices.DeleteFunc(t.pins[m.ChannelID], func(p *discordgo.Message) bool {
+		return p.ID == m.ID
+	}), 0, m)
+
+	return true
+}
+
+func (t *transport) unpin(channelID, messageID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pins[channelID] = slices.DeleteFunc(t.pins[channelID], func(p *discordgo.Message) bool {
+		return p.ID == messageID
+	})
+}
+
+// pinned returns the channel's native pins, most recently pinned first
+func (t *transport) pinned(channelID string) []*discordgo.Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pinned := slices.Clone(t.pins[channelID])
+	if pinned == nil {
+		return []*discordgo.Message{}
+	}
+
+	return pinned
+}
+
+// messageComponents returns the components last sent with the message or interaction response
+func (t *transport) messageComponents(key string) []discordgo.MessageComponent {
+	t.mu.Lock()
+	raw, ok := t.components[key]
+	t.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	// components can only be unmarshalled as part of a message
+	m := &discordgo.Message{}
+	if err := json.Unmarshal([]byte(`{"components":`+string(raw)+`}`), m); err != nil {
+		return nil
+	}
+
+	return m.Components
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/api/v9/"), "/")
+
+	var key string
+	switch {
+	// GET attachments from the CDN
+	case req.Method == http.MethodGet && req.URL.Host == "cdn.discordapp.com":
+		return attachment(req)
+	// GET users/@me
+	case req.Method == http.MethodGet && len(parts) == 2 && parts[0] == "users" && parts[1] == "@me":
+		return response(req, http.StatusOK, `{"id": "`+testAppID+`", "username": "Pinbot", "bot": true}`), nil
+	// GET channels/:channel/messages
+	case req.Method == http.MethodGet && len(parts) == 3 && parts[0] == "channels" && parts[2] == "messages":
+		limit, _ := strconv.Atoi(req.URL.Query().Get("limit"))
+		bs, err := json.Marshal(t.messages(parts[1], req.URL.Query().Get("before"), limit))
+		if err != nil {
+			return nil, err
+		}
+
+		return response(req, http.StatusOK, string(bs)), nil
+	// GET channels/:channel/messages/:message
+	case req.Method == http.MethodGet && len(parts) == 4 && parts[0] == "channels" && parts[2] == "messages":
+		if m, ok := t.editedMessage(parts[3]); ok {
+			bs, err := json.Marshal(m)
+			if err != nil {
+				return nil, err
+			}
+
+			return response(req, http.StatusOK, string(bs)), nil
+		}
+
+		return http.DefaultTransport.RoundTrip(req)
+	// GET channels/:channel/pins
+	case req.Method == http.MethodGet && len(parts) == 3 && parts[0] == "channels" && parts[2] == "pins":
+		bs, err := json.Marshal(t.pinned(parts[1]))
+		if err != nil {
+			return nil, err
+		}
+
+		return response(req, http.StatusOK, string(bs)), nil
+	// PUT channels/:channel/pins/:message
+	case req.Method == http.MethodPut && len(parts) == 4 && parts[0] == "channels" && parts[2] == "pins":
+		m, res, err := t.message(req, parts[1], parts[3])
+		if m == nil {
+			return res, err
+		}
+
+		if !t.pin(m) {
+			return response(req, http.StatusBadRequest, fmt.Sprintf(`{"code": %d, "message": "Maximum number of pins reached (%d)"}`, discordgo.ErrCodeMaximumPinsReached, maxPins)), nil
+		}
+
+		return response(req, http.StatusNoContent, ""), nil
+	// DELETE channels/:channel/pins/:message
+	case req.Method == http.MethodDelete && len(parts) == 4 && parts[0] == "channels" && parts[2] == "pins":
+		t.unpin(parts[1], parts[3])
+
+		return response(req, http.StatusNoContent, ""), nil
+	// GET guilds/:guild
+	case req.Method == http.MethodGet && len(parts) == 2 && parts[0] == "guilds":
+		return withEveryoneRole(http.DefaultTransport.RoundTrip(req))
+	// GET guilds/:guild/threads/active
+	case req.Method == http.MethodGet && len(parts) == 4 && parts[0] == "guilds" && parts[2] == "threads" && parts[3] == "active":
+		return response(req, http.StatusOK, `{"threads": [], "members": []}`), nil
+	// GET guilds/:guild/members/:user
+	case req.Method == http.MethodGet && len(parts) == 4 && parts[0] == "guilds" && parts[2] == "members":
+		if m, ok := t.member(parts[3]); ok {
+			bs, err := json.Marshal(m)
+			if err != nil {
+				return nil, err
+			}
+
+			return response(req, http.StatusOK, string(bs)), nil
+		}
+	// POST channels/:channel/messages
+	case req.Method == http.MethodPost && len(parts) == 3 && parts[0] == "channels" && parts[2] == "messages":
+		if t.isDenied(parts[1]) {
+			return response(req, http.StatusForbidden, `{"code": 50013, "message": "Missing Permissions"}`), nil
+		}
+	// POST interactions/:interaction/:token/callback
+	case req.Method == http.MethodPost && len(parts) == 4 && parts[0] == "interactions" && parts[3] == "callback":
+		return deferUpdate(req)
+	// POST webhooks/:application/:token
+	case req.Method == http.MethodPost && len(parts) == 3 && parts[0] == "webhooks":
+		return t.followup(req, parts[2])
+	// DELETE webhooks/:application/:token/messages/@original
+	case req.Method == http.MethodDelete && len(parts) == 5 && parts[0] == "webhooks" && parts[4] == "@original":
+		t.updateResponse(parts[2], func(r *interactionResponse) {
+			r.deleted = true
+		})
+
+		return response(req, http.StatusNoContent, ""), nil
+	// PATCH channels/:channel/messages/:message
+	case req.Method == http.MethodPatch && len(parts) == 4 && parts[0] == "channels" && parts[2] == "messages":
+		return t.edit(req, parts[1], parts[3])
+	// PATCH webhooks/:application/:token/messages/@original
+	case req.Method == http.MethodPatch && len(parts) == 5 && parts[0] == "webhooks" && parts[4] == "@original":
+		key = parts[2]
+	default:
+		return http.DefaultTransport.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+
+	components, err := removeComponents(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil || res.StatusCode != http.StatusOK || components == nil {
+		return res, err
+	}
+
+	if key == "" {
+		// new messages are keyed by the ID they are created with
+		bs, err := io.ReadAll(res.Body)
+		if err != nil {
+			return nil, err
+		}
+		res.Body = io.NopCloser(bytes.NewReader(bs))
+
+		m := &struct {
+			ID string `json:"id"`
+		}{}
+		if err := json.Unmarshal(bs, m); err != nil {
+			return nil, err
+		}
+		key = m.ID
+	}
+
+	t.mu.Lock()
+	t.components[key] = components
+	t.mu.Unlock()
+
+	return res, nil
+}
+
+// messages returns a page of the channel's history before the message if one is given, newest first as Discord returns
+// it
+func (t *transport) messages(channelID, before string, limit int) []*discordgo.Message {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	messages := slices.Clone(t.history[channelID])
+	slices.Reverse(messages)
+
+	if n := slices.IndexFunc(messages, func(m *discordgo.Message) bool { return m.ID == before }); n >= 0 {
+		messages = messages[n+1:]
+	}
+	if limit > 0 && len(messages) > limit {
+		messages = messages[:limit]
+	}
+
+	if messages == nil {
+		return []*discordgo.Message{}
+	}
+
+	return messages
+}
+
+func (t *transport) member(userID string) (*discordgo.Member, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m, ok := t.members[userID]
+
+	return m, ok
+}
+
+// permissionsEveryone are the permissions of the @everyone role in the test guild
+const permissionsEveryone = discordgo.PermissionViewChannel |
+	discordgo.PermissionSendMessages |
+	discordgo.PermissionReadMessageHistory |
+	discordgo.PermissionAddReactions |
+	discordgo.PermissionEmbedLinks |
+	discordgo.PermissionAttachFiles
+
+// testOwnerID is the ID of the test guild's owner, who isn't otherwise involved in the tests
+const testOwnerID = "1"
+
+// withEveryoneRole adds the @everyone role and an owner to the guild in the response, as fakediscord's guilds have
+// neither
+func withEveryoneRole(res *http.Response, err error) (*http.Response, error) {
+	if err != nil || res.StatusCode != http.StatusOK {
+		return res, err
+	}
+	defer res.Body.Close()
+
+	g := &discordgo.Guild{}
+	if err := json.NewDecoder(res.Body).Decode(g); err != nil {
+		return nil, err
+	}
+
+	if len(g.Roles) == 0 {
+		g.Roles = []*discordgo.Role{{ID: g.ID, Name: "@everyone", Permissions: permissionsEveryone}}
+	}
+	if g.OwnerID == "" {
+		g.OwnerID = testOwnerID
+	}
+
+	bs, err := json.Marshal(g)
+	if err != nil {
+		return nil, err
+	}
+
+	return response(res.Request, http.StatusOK, string(bs)), nil
+}
+
+func (t *transport) isDenied(channelID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.denied[channelID]
+}
+
+// removeComponents removes the components from the request's JSON payload, returning them. Multipart edits are
+// replaced by their JSON payload, as fakediscord can't receive files when editing messages.
+func removeComponents(req *http.Request) (json.RawMessage, error) {
+	payload, err := readPayload(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]json.RawMessage{}
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, err
+	}
+
+	components := body["components"]
+	delete(body, "components")
+
+	bs, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if isMultipart(req) && req.Method == http.MethodPost {
+		return components, withPayload(req, bs)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Body = io.NopCloser(bytes.NewReader(bs))
+	req.ContentLength = int64(len(bs))
+
+	return components, nil
+}
+
+// readPayload reads the JSON payload of the request, which may be multipart if it has files
+func readPayload(req *http.Request) ([]byte, error) {
+	if !isMultipart(req) {
+		return io.ReadAll(req.Body)
+	}
+
+	if err := req.ParseMultipartForm(10 << 20); err != nil {
+		return nil, err
+	}
+
+	return []byte(req.MultipartForm.Value["payload_json"][0]), nil
+}
+
+// withPayload replaces the parsed multipart request's body with the payload and its original files
+func withPayload(req *http.Request, payload []byte) error {
+	buf := &bytes.Buffer{}
+	w := multipart.NewWriter(buf)
+
+	if err := w.WriteField("payload_json", string(payload)); err != nil {
+		return err
+	}
+
+	for field, headers := range req.MultipartForm.File {
+		for _, h := range headers {
+			part, err := w.CreatePart(textproto.MIMEHeader{
+				"Content-Disposition": []string{fmt.Sprintf(`form-data; name="%s"; filename="%s"`, field, h.Filename)},
+				"Content-Type":        h.Header.Values("Content-Type"),
+			})
+			if err != nil {
+				return err
+			}
+
+			f, err := h.Open()
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(part, f)
+			_ = f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Body = io.NopCloser(buf)
+	req.ContentLength = int64(buf.Len())
+
+	return nil
+}
+
+// attachment serves attachments from the test files, as fakediscord only invents their URLs
+func attachment(req *http.Request) (*http.Response, error) {
+	bs, err := os.ReadFile(filepath.Join("files", path.Base(req.URL.Path)))
+	if err != nil {
+		return response(req, http.StatusNotFound, ""), nil
+	}
+
+	res := response(req, http.StatusOK, string(bs))
+	res.Header.Set("Content-Type", mime.TypeByExtension(path.Ext(req.URL.Path)))
+
+	return res, nil
+}
+
+// deferUpdate acknowledges deferred message updates, which fakediscord doesn't implement, and forwards any other
+// callback
+func deferUpdate(req *http.Request) (*http.Response, error) {
+	bs, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(bs))
+
+	res := &struct {
+		Type discordgo.InteractionResponseType `json:"type"`
+	}{}
+	if err := json.Unmarshal(bs, res); err != nil {
+		return nil, err
+	}
+
+	if res.Type == discordgo.InteractionResponseDeferredMessageUpdate {
+		return response(req, http.StatusNoContent, ""), nil
+	}
+
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// followup records the followup message to the interaction, responding as Discord would
+func (t *transport) followup(req *http.Request, token string) (*http.Response, error) {
+	payload, err := readPayload(req)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &discordgo.Message{}
+	if err := json.Unmarshal(payload, m); err != nil {
+		return nil, err
+	}
+
+	t.updateResponse(token, func(r *interactionResponse) {
+		m.ID = fmt.Sprintf("%s-%d", token, len(r.followups))
+		r.followups = append(r.followups, m)
+	})
+
+	bs, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return response(req, http.StatusOK, string(bs)), nil
+}
+
+func isMultipart(req *http.Request) bool {
+	return strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data")
+}
+
+func response(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+// edit applies the edit to the message, which is kept in place of fakediscord's message as it can't edit them
+func (t *transport) edit(req *http.Request, channelID, messageID string) (*http.Response, error) {
+	req = req.Clone(req.Context())
+
+	components, err := removeComponents(req)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &struct {
+		Content *string                    `json:"content"`
+		Embeds  *[]*discordgo.MessageEmbed `json:"embeds"`
+	}{}
+	if err := json.NewDecoder(req.Body).Decode(e); err != nil {
+		return nil, err
+	}
+
+	m, res, err := t.message(req, channelID, messageID)
+	if m == nil {
+		return res, err
+	}
+	c := *m
+	m = &c
+
+	if e.Content != nil {
+		m.Content = *e.Content
+	}
+	if e.Embeds != nil {
+		m.Embeds = *e.Embeds
+	}
+	m.EditedTimestamp = new(time.Time)
+	*m.EditedTimestamp = time.Now()
+
+	t.mu.Lock()
+	t.edited[messageID] = m
+	if components != nil {
+		t.components[messageID] = components
+	}
+	t.mu.Unlock()
+
+	bs, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return response(req, http.StatusOK, string(bs)), nil
+}
+
+// message gets the message for the request, returning fakediscord's response instead if it couldn't be found
+func (t *transport) message(req *http.Request, channelID, messageID string) (*discordgo.Message, *http.Response, error) {
+	if m, ok := t.editedMessage(messageID); ok {
+		return m, nil, nil
+	}
+
+	u := *req.URL
+	u.Path = "/api/v9/channels/" + channelID + "/messages/" + messageID
+
+	get, err := http.NewRequestWithContext(req.Context(), http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	get.Header.Set("Authorization", req.Header.Get("Authorization"))
+
+	res, err := http.DefaultTransport.RoundTrip(get)
+	if err != nil || res.StatusCode != http.StatusOK {
+		return nil, res, err
+	}
+	defer res.Body.Close()
+
+	m := &discordgo.Message{}
+	if err := json.NewDecoder(res.Body).Decode(m); err != nil {
+		return nil, nil, err
+	}
+
+	return m, nil, nil
+}